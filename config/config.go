@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -14,20 +16,29 @@ var defaultConf = []byte(`
 # example: debug, release, test
 CORE_MODE=debug
 CORE_PORT=8080
+CORE_GRPC_PORT=9090
+# example: memory, sqlite
+CORE_STORAGE=memory
 `)
 
 var Conf ConfENV
 var once sync.Once
 
 type ConfENV struct {
-	Core   SectionCore
-	Log    SectionLog
-	SQLite SectionSQLite
+	Core     SectionCore
+	Log      SectionLog
+	SQLite   SectionSQLite
+	Snapshot SectionSnapshot
+	WAL      SectionWAL
+	Match    SectionMatch
+	Score    SectionScore
 }
 
 type SectionCore struct {
-	Mode string
-	Port string
+	Mode     string
+	Port     string
+	GRPCPort string
+	Storage  string
 }
 
 type SectionLog struct {
@@ -41,6 +52,27 @@ type SectionSQLite struct {
 	MaxConn  int
 }
 
+type SectionSnapshot struct {
+	Path     string
+	Interval time.Duration
+}
+
+type SectionWAL struct {
+	Path string
+}
+
+type SectionMatch struct {
+	Policy          string
+	HeightTolerance float64
+	LikeTTL         time.Duration
+	AllowRematch    bool
+}
+
+type SectionScore struct {
+	// Weights of the candidate scorers by name, empty weighs every scorer the same
+	Weights map[string]float64
+}
+
 func InitConf(confPath string) error {
 	var err error
 	once.Do(func() {
@@ -89,6 +121,11 @@ func LoadConf(confPath string) (ConfENV, error) {
 	if len(conf.Core.Port) == 0 {
 		conf.Core.Port = "8080"
 	}
+	conf.Core.GRPCPort = viper.GetString("core_grpc_port")
+	if len(conf.Core.GRPCPort) == 0 {
+		conf.Core.GRPCPort = "9090"
+	}
+	conf.Core.Storage = viper.GetString("core_storage")
 
 	conf.Log.Format = viper.GetString("log_format")
 	conf.Log.Level = viper.GetString("log_level")
@@ -97,5 +134,41 @@ func LoadConf(confPath string) (ConfENV, error) {
 	conf.SQLite.Database = viper.GetString("sqlite_database")
 	conf.SQLite.MaxConn = viper.GetInt("sqlite_db_max_conn")
 
+	conf.Snapshot.Path = viper.GetString("snapshot_path")
+	conf.Snapshot.Interval = viper.GetDuration("snapshot_interval")
+
+	conf.WAL.Path = viper.GetString("wal_path")
+
+	conf.Match.Policy = viper.GetString("match_policy")
+	conf.Match.HeightTolerance = viper.GetFloat64("match_height_tolerance")
+	conf.Match.LikeTTL = viper.GetDuration("match_like_ttl")
+	conf.Match.AllowRematch = viper.GetBool("match_allow_rematch")
+
+	weights, err := parseWeights(viper.GetString("score_weights"))
+	if err != nil {
+		return conf, err
+	}
+	conf.Score.Weights = weights
+
 	return conf, nil
 }
+
+// parseWeights reads weights like "height_gap=1,shared_interests=2"
+func parseWeights(s string) (map[string]float64, error) {
+	weights := map[string]float64{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("score weight %q should be name=weight", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("score weight %q: %w", pair, err)
+		}
+		weights[strings.TrimSpace(name)] = weight
+	}
+	return weights, nil
+}
@@ -2,10 +2,58 @@ package constant
 
 type Gender string
 
+type MatchStatus string
+
+type ReactionKind string
+
+type CandidateSort string
+
+type ReportStatus string
+
+type EventType string
+
 const (
 	ServiceName        = "matching-system"
 	ResponseCodePrefix = 1
 
-	GenderMale   Gender = "male"
-	GenderFemale Gender = "female"
+	GenderMale      Gender = "male"
+	GenderFemale    Gender = "female"
+	GenderNonBinary Gender = "non_binary"
+
+	MatchStatusMatched MatchStatus = "matched"
+
+	ReactionLike ReactionKind = "like"
+	ReactionPass ReactionKind = "pass"
+	// ReactionBlock hides both people from each other for good
+	ReactionBlock ReactionKind = "block"
+
+	ReportOpen      ReportStatus = "open"
+	ReportDismissed ReportStatus = "dismissed"
+	// ReportActioned reports were resolved by suspending the reported person
+	ReportActioned ReportStatus = "actioned"
+
+	// EventCandidateJoined tells a person that someone who is among their candidates joined
+	EventCandidateJoined EventType = "candidate_joined"
+	EventMatched         EventType = "matched"
+	// EventRemoved is the last event of a person, sent when they are removed or run out of dates
+	EventRemoved EventType = "removed"
+
+	SortNearest  CandidateSort = "nearest"
+	SortTallest  CandidateSort = "tallest"
+	SortShortest CandidateSort = "shortest"
+	SortRandom   CandidateSort = "random"
+
+	MatchPolicyTallerMale      = "taller_male"
+	MatchPolicyHeightTolerance = "height_tolerance"
+
+	ScorerHeightGap       = "height_gap"
+	ScorerSharedInterests = "shared_interests"
+	ScorerDistance        = "distance"
+	ScorerActivity        = "activity"
+
+	StorageMemory = "memory"
+	StorageSQLite = "sqlite"
 )
+
+// Genders lists every supported gender, each one has its own person tree
+var Genders = []Gender{GenderMale, GenderFemale, GenderNonBinary}
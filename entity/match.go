@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+)
+
+type Match struct {
+	ID        uint64
+	PersonID1 uint64
+	PersonID2 uint64
+	Status    constant.MatchStatus
+	CreatedAt time.Time
+}
@@ -1,11 +1,92 @@
 package entity
 
-import "github.com/ars0915/matching-system/constant"
+import (
+	"math"
+
+	"github.com/ars0915/matching-system/constant"
+)
 
 type Person struct {
-	ID          uint64
-	Name        string
-	Height      float64
-	Gender      constant.Gender
+	ID      uint64
+	Name    string
+	Height  float64
+	Gender  constant.Gender
+	Seeking []constant.Gender
+	// SeekingDefaulted is set when Seeking was derived from Gender rather than declared,
+	// so it follows a change of gender
+	SeekingDefaulted bool `json:",omitempty"`
+	WantedDates      *uint64
+	// Age is 0 when unknown
+	Age      int
+	City     string
+	Location *Location
+	Tags     []string
+	// Version is bumped by every profile update, an update naming another version is refused
+	Version uint64
+}
+
+// PersonPatch changes the profile of the person at Version, nil fields are kept
+type PersonPatch struct {
+	Name        *string
+	Height      *float64
+	Gender      *constant.Gender
+	Seeking     []constant.Gender
 	WantedDates *uint64
+	Version     uint64
+}
+
+// Seeks reports whether the person is looking for someone of gender g
+func (p Person) Seeks(g constant.Gender) bool {
+	for _, s := range p.Seeking {
+		if s == g {
+			return true
+		}
+	}
+	return false
+}
+
+// CompatibleWith reports whether both people seek each other's gender
+func (p Person) CompatibleWith(other Person) bool {
+	return p.Seeks(other.Gender) && other.Seeks(p.Gender)
+}
+
+// DefaultSeeking is used for persons who did not declare whom they seek,
+// which keeps the male-female matching of persons created before seeking existed
+func DefaultSeeking(g constant.Gender) []constant.Gender {
+	switch g {
+	case constant.GenderMale:
+		return []constant.Gender{constant.GenderFemale}
+	case constant.GenderFemale:
+		return []constant.Gender{constant.GenderMale}
+	}
+	return nil
+}
+
+// HasAnyTag reports whether the person has at least one of tags
+func (p Person) HasAnyTag(tags []string) bool {
+	for _, t := range p.Tags {
+		for _, want := range tags {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const earthRadiusKm = 6371.0
+
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// DistanceKm is the great-circle distance between l and other
+func (l Location) DistanceKm(other Location) float64 {
+	lat1, lat2 := l.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (other.Longitude - l.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
 }
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+)
+
+// Reaction is the decision of a person on a candidate
+type Reaction struct {
+	PersonID    uint64
+	CandidateID uint64
+	Kind        constant.ReactionKind
+	CreatedAt   time.Time
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+)
+
+// Report is a complaint of a person about another, waiting in the moderation queue while open
+type Report struct {
+	ID         uint64
+	ReporterID uint64
+	ReportedID uint64
+	Reason     string
+	Status     constant.ReportStatus
+	CreatedAt  time.Time
+	// ResolvedAt is nil while the report is open
+	ResolvedAt *time.Time
+}
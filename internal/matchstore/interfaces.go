@@ -0,0 +1,29 @@
+package matchstore
+
+import "github.com/ars0915/matching-system/entity"
+
+//go:generate mockgen -destination=../mocks/matchstore/match_store.go -package=mocks github.com/ars0915/matching-system/internal/matchstore Store
+type (
+	Store interface {
+		MatchStoreIface
+	}
+)
+
+type (
+	MatchStoreIface interface {
+		AddMatch(m *entity.Match) error
+		FindByID(id uint64) (*entity.Match, bool, error)
+		List(offset, limit int) ([]entity.Match, int, error)
+		ListByPerson(personID uint64, offset, limit int) ([]entity.Match, int, error)
+		// Matched reports whether the two persons were ever matched, in either order
+		Matched(id1, id2 uint64) (bool, error)
+		// Partners returns the persons ever matched with the person, ordered by match ID
+		Partners(personID uint64) ([]uint64, error)
+	}
+
+	// DatesStore is implemented by a Store that keeps the persons too, so it can
+	// record a match with the remaining dates of its persons in one transaction
+	DatesStore interface {
+		AddMatchWithDates(m *entity.Match, persons ...*entity.Person) error
+	}
+)
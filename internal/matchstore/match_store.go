@@ -0,0 +1,123 @@
+package matchstore
+
+import (
+	"sync"
+
+	"github.com/ars0915/matching-system/entity"
+)
+
+type MatchStore struct {
+	matches   []*entity.Match
+	idMap     map[uint64]*entity.Match
+	personMap map[uint64][]*entity.Match
+	lastID    uint64
+	mu        sync.RWMutex
+}
+
+func NewMatchStore() *MatchStore {
+	return &MatchStore{
+		idMap:     map[uint64]*entity.Match{},
+		personMap: map[uint64][]*entity.Match{},
+	}
+}
+
+// AddMatch stores the match and assigns it the next ID
+func (ms *MatchStore) AddMatch(m *entity.Match) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// Restored matches keep their ID
+	if m.ID == 0 {
+		m.ID = ms.lastID + 1
+	}
+	if m.ID > ms.lastID {
+		ms.lastID = m.ID
+	}
+
+	stored := *m
+	ms.matches = append(ms.matches, &stored)
+	ms.idMap[stored.ID] = &stored
+	ms.personMap[stored.PersonID1] = append(ms.personMap[stored.PersonID1], &stored)
+	ms.personMap[stored.PersonID2] = append(ms.personMap[stored.PersonID2], &stored)
+
+	return nil
+}
+
+func (ms *MatchStore) FindByID(id uint64) (*entity.Match, bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	m, exist := ms.idMap[id]
+	if !exist {
+		return nil, false, nil
+	}
+
+	match := *m
+	return &match, true, nil
+}
+
+// List returns matches ordered by ID and the total number of matches
+func (ms *MatchStore) List(offset, limit int) ([]entity.Match, int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return page(ms.matches, offset, limit), len(ms.matches), nil
+}
+
+// ListByPerson returns matches of the person ordered by ID and the total number of them
+func (ms *MatchStore) ListByPerson(personID uint64, offset, limit int) ([]entity.Match, int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	matches := ms.personMap[personID]
+	return page(matches, offset, limit), len(matches), nil
+}
+
+func (ms *MatchStore) Matched(id1, id2 uint64) (bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, m := range ms.personMap[id1] {
+		if m.PersonID1 == id2 || m.PersonID2 == id2 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ms *MatchStore) Partners(personID uint64) ([]uint64, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	matches := ms.personMap[personID]
+	partners := make([]uint64, 0, len(matches))
+	for _, m := range matches {
+		if m.PersonID1 == personID {
+			partners = append(partners, m.PersonID2)
+		} else {
+			partners = append(partners, m.PersonID1)
+		}
+	}
+	return partners, nil
+}
+
+// page copies matches[offset:offset+limit], a negative limit means no limit
+func page(matches []*entity.Match, offset, limit int) []entity.Match {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(matches) {
+		return nil
+	}
+
+	end := len(matches)
+	if limit >= 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	result := make([]entity.Match, 0, end-offset)
+	for _, m := range matches[offset:end] {
+		result = append(result, *m)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package matchstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+type matchStoreTestSuite struct {
+	suite.Suite
+
+	ms *MatchStore
+}
+
+func Test_matchStoreTestSuite(t *testing.T) {
+	suite.Run(t, &matchStoreTestSuite{})
+}
+
+func (s *matchStoreTestSuite) SetupTest() {
+	s.ms = NewMatchStore()
+
+	pairs := [][2]uint64{{1, 2}, {1, 3}, {4, 2}, {1, 5}}
+	for _, pair := range pairs {
+		err := s.ms.AddMatch(&entity.Match{
+			PersonID1: pair[0],
+			PersonID2: pair[1],
+			Status:    constant.MatchStatusMatched,
+		})
+		assert.Nil(s.T(), err)
+	}
+}
+
+func (s *matchStoreTestSuite) Test_AddMatch() {
+	m := entity.Match{
+		PersonID1: 6,
+		PersonID2: 7,
+		Status:    constant.MatchStatusMatched,
+	}
+	err := s.ms.AddMatch(&m)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(5), m.ID)
+
+	got, exist, err := s.ms.FindByID(m.ID)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "match should be found")
+	assert.Equal(s.T(), m, *got)
+}
+
+func (s *matchStoreTestSuite) Test_List() {
+	tests := []struct {
+		name    string
+		offset  int
+		limit   int
+		wantIDs []uint64
+	}{
+		{"First page", 0, 2, []uint64{1, 2}},
+		{"Last page", 2, 2, []uint64{3, 4}},
+		{"Out of range", 10, 2, nil},
+		{"Unlimited", 1, -1, []uint64{2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			got, total, err := s.ms.List(tt.offset, tt.limit)
+			assert.Nil(t, err)
+			assert.Equal(t, 4, total)
+			assert.Equal(t, tt.wantIDs, matchIDs(got))
+		})
+	}
+}
+
+func (s *matchStoreTestSuite) Test_ListByPerson() {
+	tests := []struct {
+		name      string
+		personID  uint64
+		offset    int
+		limit     int
+		wantIDs   []uint64
+		wantTotal int
+	}{
+		{"As first person", 1, 0, 10, []uint64{1, 2, 4}, 3},
+		{"As second person", 2, 0, 10, []uint64{1, 3}, 2},
+		{"Paged", 1, 1, 1, []uint64{2}, 3},
+		{"No matches", 99, 0, 10, nil, 0},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			got, total, err := s.ms.ListByPerson(tt.personID, tt.offset, tt.limit)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Equal(t, tt.wantIDs, matchIDs(got))
+		})
+	}
+}
+
+func (s *matchStoreTestSuite) Test_Matched() {
+	tests := []struct {
+		name     string
+		id1, id2 uint64
+		want     bool
+	}{
+		{"Same order", 1, 2, true},
+		{"Reversed", 2, 4, true},
+		{"Never matched", 2, 3, false},
+		{"Unknown person", 99, 1, false},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			got, err := s.ms.Matched(tt.id1, tt.id2)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func (s *matchStoreTestSuite) Test_Partners() {
+	partners, err := s.ms.Partners(1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{2, 3, 5}, partners)
+
+	partners, err = s.ms.Partners(2)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{1, 4}, partners)
+
+	partners, err = s.ms.Partners(99)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), partners)
+}
+
+func matchIDs(matches []entity.Match) []uint64 {
+	var ids []uint64
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
@@ -0,0 +1,127 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ars0915/matching-system/internal/matchstore (interfaces: Store)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	entity "github.com/ars0915/matching-system/entity"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// AddMatch mocks base method.
+func (m *MockStore) AddMatch(arg0 *entity.Match) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMatch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMatch indicates an expected call of AddMatch.
+func (mr *MockStoreMockRecorder) AddMatch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMatch", reflect.TypeOf((*MockStore)(nil).AddMatch), arg0)
+}
+
+// FindByID mocks base method.
+func (m *MockStore) FindByID(arg0 uint64) (*entity.Match, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", arg0)
+	ret0, _ := ret[0].(*entity.Match)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockStoreMockRecorder) FindByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockStore)(nil).FindByID), arg0)
+}
+
+// List mocks base method.
+func (m *MockStore) List(arg0, arg1 int) ([]entity.Match, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].([]entity.Match)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockStoreMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStore)(nil).List), arg0, arg1)
+}
+
+// ListByPerson mocks base method.
+func (m *MockStore) ListByPerson(arg0 uint64, arg1, arg2 int) ([]entity.Match, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPerson", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Match)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByPerson indicates an expected call of ListByPerson.
+func (mr *MockStoreMockRecorder) ListByPerson(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPerson", reflect.TypeOf((*MockStore)(nil).ListByPerson), arg0, arg1, arg2)
+}
+
+// Matched mocks base method.
+func (m *MockStore) Matched(arg0, arg1 uint64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Matched", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Matched indicates an expected call of Matched.
+func (mr *MockStoreMockRecorder) Matched(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Matched", reflect.TypeOf((*MockStore)(nil).Matched), arg0, arg1)
+}
+
+// Partners mocks base method.
+func (m *MockStore) Partners(arg0 uint64) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Partners", arg0)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Partners indicates an expected call of Partners.
+func (mr *MockStoreMockRecorder) Partners(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Partners", reflect.TypeOf((*MockStore)(nil).Partners), arg0)
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ars0915/matching-system/internal/reportstore (interfaces: Store)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	constant "github.com/ars0915/matching-system/constant"
+	entity "github.com/ars0915/matching-system/entity"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// AddReport mocks base method.
+func (m *MockStore) AddReport(arg0 *entity.Report) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReport", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReport indicates an expected call of AddReport.
+func (mr *MockStoreMockRecorder) AddReport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReport", reflect.TypeOf((*MockStore)(nil).AddReport), arg0)
+}
+
+// FindByID mocks base method.
+func (m *MockStore) FindByID(arg0 uint64) (*entity.Report, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", arg0)
+	ret0, _ := ret[0].(*entity.Report)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockStoreMockRecorder) FindByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockStore)(nil).FindByID), arg0)
+}
+
+// List mocks base method.
+func (m *MockStore) List(arg0 constant.ReportStatus, arg1, arg2 int) ([]entity.Report, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Report)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockStoreMockRecorder) List(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStore)(nil).List), arg0, arg1, arg2)
+}
+
+// Resolve mocks base method.
+func (m *MockStore) Resolve(arg0 uint64, arg1 constant.ReportStatus, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockStoreMockRecorder) Resolve(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockStore)(nil).Resolve), arg0, arg1, arg2)
+}
+
+// ResolveReported mocks base method.
+func (m *MockStore) ResolveReported(arg0 uint64, arg1 constant.ReportStatus, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveReported", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveReported indicates an expected call of ResolveReported.
+func (mr *MockStoreMockRecorder) ResolveReported(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveReported", reflect.TypeOf((*MockStore)(nil).ResolveReported), arg0, arg1, arg2)
+}
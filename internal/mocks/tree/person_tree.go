@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	entity "github.com/ars0915/matching-system/entity"
+	tree "github.com/ars0915/matching-system/internal/tree"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -48,6 +49,20 @@ func (mr *MockTreeMockRecorder) AddPerson(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPerson", reflect.TypeOf((*MockTree)(nil).AddPerson), arg0)
 }
 
+// BulkAdd mocks base method.
+func (m *MockTree) BulkAdd(arg0 []*entity.Person) []error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkAdd", arg0)
+	ret0, _ := ret[0].([]error)
+	return ret0
+}
+
+// BulkAdd indicates an expected call of BulkAdd.
+func (mr *MockTreeMockRecorder) BulkAdd(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkAdd", reflect.TypeOf((*MockTree)(nil).BulkAdd), arg0)
+}
+
 // FindByID mocks base method.
 func (m *MockTree) FindByID(arg0 uint64) (*entity.Person, bool) {
 	m.ctrl.T.Helper()
@@ -77,6 +92,49 @@ func (mr *MockTreeMockRecorder) QueryByHeight(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryByHeight", reflect.TypeOf((*MockTree)(nil).QueryByHeight), arg0, arg1)
 }
 
+// QueryNearest mocks base method.
+func (m *MockTree) QueryNearest(arg0 float64, arg1 tree.Query, arg2 int) []entity.Person {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryNearest", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Person)
+	return ret0
+}
+
+// QueryNearest indicates an expected call of QueryNearest.
+func (mr *MockTreeMockRecorder) QueryNearest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryNearest", reflect.TypeOf((*MockTree)(nil).QueryNearest), arg0, arg1, arg2)
+}
+
+// QueryOrdered mocks base method.
+func (m *MockTree) QueryOrdered(arg0 tree.Query, arg1 bool, arg2 int) []entity.Person {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryOrdered", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]entity.Person)
+	return ret0
+}
+
+// QueryOrdered indicates an expected call of QueryOrdered.
+func (mr *MockTreeMockRecorder) QueryOrdered(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryOrdered", reflect.TypeOf((*MockTree)(nil).QueryOrdered), arg0, arg1, arg2)
+}
+
+// QuerySample mocks base method.
+func (m *MockTree) QuerySample(arg0 tree.Query, arg1 int) ([]entity.Person, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuerySample", arg0, arg1)
+	ret0, _ := ret[0].([]entity.Person)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// QuerySample indicates an expected call of QuerySample.
+func (mr *MockTreeMockRecorder) QuerySample(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuerySample", reflect.TypeOf((*MockTree)(nil).QuerySample), arg0, arg1)
+}
+
 // RemovePerson mocks base method.
 func (m *MockTree) RemovePerson(arg0 uint64) error {
 	m.ctrl.T.Helper()
@@ -90,3 +148,73 @@ func (mr *MockTreeMockRecorder) RemovePerson(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePerson", reflect.TypeOf((*MockTree)(nil).RemovePerson), arg0)
 }
+
+// Snapshot mocks base method.
+func (m *MockTree) Snapshot() []entity.Person {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snapshot")
+	ret0, _ := ret[0].([]entity.Person)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot.
+func (mr *MockTreeMockRecorder) Snapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockTree)(nil).Snapshot))
+}
+
+// Suspend mocks base method.
+func (m *MockTree) Suspend(arg0 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suspend", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Suspend indicates an expected call of Suspend.
+func (mr *MockTreeMockRecorder) Suspend(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspend", reflect.TypeOf((*MockTree)(nil).Suspend), arg0)
+}
+
+// Suspended mocks base method.
+func (m *MockTree) Suspended() []entity.Person {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Suspended")
+	ret0, _ := ret[0].([]entity.Person)
+	return ret0
+}
+
+// Suspended indicates an expected call of Suspended.
+func (mr *MockTreeMockRecorder) Suspended() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspended", reflect.TypeOf((*MockTree)(nil).Suspended))
+}
+
+// Unsuspend mocks base method.
+func (m *MockTree) Unsuspend(arg0 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsuspend", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsuspend indicates an expected call of Unsuspend.
+func (mr *MockTreeMockRecorder) Unsuspend(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsuspend", reflect.TypeOf((*MockTree)(nil).Unsuspend), arg0)
+}
+
+// UpdatePerson mocks base method.
+func (m *MockTree) UpdatePerson(arg0 *entity.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePerson", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePerson indicates an expected call of UpdatePerson.
+func (mr *MockTreeMockRecorder) UpdatePerson(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePerson", reflect.TypeOf((*MockTree)(nil).UpdatePerson), arg0)
+}
@@ -0,0 +1,25 @@
+package reactionstore
+
+import "github.com/ars0915/matching-system/entity"
+
+//go:generate mockgen -destination=../mocks/reactionstore/reaction_store.go -package=mocks github.com/ars0915/matching-system/internal/reactionstore Store
+type (
+	Store interface {
+		ReactionStoreIface
+	}
+)
+
+type (
+	ReactionStoreIface interface {
+		// Put saves the reaction, replacing the previous reaction of the person on the same candidate
+		Put(r entity.Reaction) error
+		Find(personID, candidateID uint64) (*entity.Reaction, bool, error)
+		Delete(personID, candidateID uint64) error
+		// Passed returns the IDs of the candidates the person passed on
+		Passed(personID uint64) ([]uint64, error)
+		// Blocked returns the IDs of the persons the person blocked or was blocked by
+		Blocked(personID uint64) ([]uint64, error)
+		// List returns every reaction ordered by person and candidate
+		List() ([]entity.Reaction, error)
+	}
+)
@@ -0,0 +1,140 @@
+package reactionstore
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+type ReactionStore struct {
+	// reactions maps a person ID to the reactions keyed by candidate ID
+	reactions map[uint64]map[uint64]entity.Reaction
+	// blockers maps a blocked person ID to the IDs of the persons blocking them
+	blockers map[uint64]map[uint64]struct{}
+	mu       sync.RWMutex
+}
+
+func NewReactionStore() *ReactionStore {
+	return &ReactionStore{
+		reactions: map[uint64]map[uint64]entity.Reaction{},
+		blockers:  map[uint64]map[uint64]struct{}{},
+	}
+}
+
+func (rs *ReactionStore) Put(r entity.Reaction) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	candidates, exist := rs.reactions[r.PersonID]
+	if !exist {
+		candidates = map[uint64]entity.Reaction{}
+		rs.reactions[r.PersonID] = candidates
+	}
+	rs.unindexBlock(candidates[r.CandidateID])
+	candidates[r.CandidateID] = r
+
+	if r.Kind == constant.ReactionBlock {
+		blockers, exist := rs.blockers[r.CandidateID]
+		if !exist {
+			blockers = map[uint64]struct{}{}
+			rs.blockers[r.CandidateID] = blockers
+		}
+		blockers[r.PersonID] = struct{}{}
+	}
+
+	return nil
+}
+
+// unindexBlock drops r from blockers when it is a block
+func (rs *ReactionStore) unindexBlock(r entity.Reaction) {
+	if r.Kind != constant.ReactionBlock {
+		return
+	}
+	blockers := rs.blockers[r.CandidateID]
+	delete(blockers, r.PersonID)
+	if len(blockers) == 0 {
+		delete(rs.blockers, r.CandidateID)
+	}
+}
+
+func (rs *ReactionStore) Find(personID, candidateID uint64) (*entity.Reaction, bool, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	r, exist := rs.reactions[personID][candidateID]
+	if !exist {
+		return nil, false, nil
+	}
+	return &r, true, nil
+}
+
+func (rs *ReactionStore) Delete(personID, candidateID uint64) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	candidates := rs.reactions[personID]
+	rs.unindexBlock(candidates[candidateID])
+	delete(candidates, candidateID)
+	if len(candidates) == 0 {
+		delete(rs.reactions, personID)
+	}
+
+	return nil
+}
+
+func (rs *ReactionStore) Passed(personID uint64) ([]uint64, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var ids []uint64
+	for id, r := range rs.reactions[personID] {
+		if r.Kind == constant.ReactionPass {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (rs *ReactionStore) Blocked(personID uint64) ([]uint64, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	seen := map[uint64]bool{}
+	for id, r := range rs.reactions[personID] {
+		if r.Kind == constant.ReactionBlock {
+			seen[id] = true
+		}
+	}
+	for id := range rs.blockers[personID] {
+		seen[id] = true
+	}
+
+	ids := make([]uint64, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (rs *ReactionStore) List() ([]entity.Reaction, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var result []entity.Reaction
+	for _, candidates := range rs.reactions {
+		for _, r := range candidates {
+			result = append(result, r)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PersonID != result[j].PersonID {
+			return result[i].PersonID < result[j].PersonID
+		}
+		return result[i].CandidateID < result[j].CandidateID
+	})
+	return result, nil
+}
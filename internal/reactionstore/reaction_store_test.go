@@ -0,0 +1,100 @@
+package reactionstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+type reactionStoreTestSuite struct {
+	suite.Suite
+
+	rs *ReactionStore
+}
+
+func Test_reactionStoreTestSuite(t *testing.T) {
+	suite.Run(t, &reactionStoreTestSuite{})
+}
+
+func (s *reactionStoreTestSuite) SetupTest() {
+	s.rs = NewReactionStore()
+
+	reactions := []entity.Reaction{
+		{PersonID: 1, CandidateID: 3, Kind: constant.ReactionPass},
+		{PersonID: 1, CandidateID: 2, Kind: constant.ReactionLike},
+		{PersonID: 2, CandidateID: 1, Kind: constant.ReactionLike},
+		{PersonID: 1, CandidateID: 4, Kind: constant.ReactionPass},
+	}
+	for _, r := range reactions {
+		assert.Nil(s.T(), s.rs.Put(r))
+	}
+}
+
+func (s *reactionStoreTestSuite) Test_PutReplaces() {
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	pass := entity.Reaction{PersonID: 1, CandidateID: 2, Kind: constant.ReactionPass, CreatedAt: at}
+	assert.Nil(s.T(), s.rs.Put(pass))
+
+	got, exist, err := s.rs.Find(1, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "reaction should be found")
+	assert.Equal(s.T(), pass, *got)
+}
+
+func (s *reactionStoreTestSuite) Test_Delete() {
+	assert.Nil(s.T(), s.rs.Delete(2, 1))
+
+	_, exist, err := s.rs.Find(2, 1)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), exist, "reaction should be deleted")
+	assert.Nil(s.T(), s.rs.Delete(2, 1), "deleting twice should not fail")
+}
+
+func (s *reactionStoreTestSuite) Test_Passed() {
+	ids, err := s.rs.Passed(1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{3, 4}, ids)
+
+	ids, err = s.rs.Passed(2)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), ids)
+}
+
+func (s *reactionStoreTestSuite) Test_List() {
+	got, err := s.rs.List()
+	assert.Nil(s.T(), err)
+
+	var pairs [][2]uint64
+	for _, r := range got {
+		pairs = append(pairs, [2]uint64{r.PersonID, r.CandidateID})
+	}
+	assert.Equal(s.T(), [][2]uint64{{1, 2}, {1, 3}, {1, 4}, {2, 1}}, pairs)
+}
+
+func (s *reactionStoreTestSuite) Test_Blocked() {
+	assert.Nil(s.T(), s.rs.Put(entity.Reaction{PersonID: 1, CandidateID: 5, Kind: constant.ReactionBlock}))
+	assert.Nil(s.T(), s.rs.Put(entity.Reaction{PersonID: 6, CandidateID: 1, Kind: constant.ReactionBlock}))
+
+	ids, err := s.rs.Blocked(1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{5, 6}, ids, "blocks of both directions should be listed")
+
+	ids, err = s.rs.Blocked(5)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{1}, ids)
+
+	// Replacing or deleting a block lifts it
+	assert.Nil(s.T(), s.rs.Put(entity.Reaction{PersonID: 1, CandidateID: 5, Kind: constant.ReactionPass}))
+	assert.Nil(s.T(), s.rs.Delete(6, 1))
+	ids, err = s.rs.Blocked(1)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), ids)
+	ids, err = s.rs.Blocked(5)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), ids)
+}
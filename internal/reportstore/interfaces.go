@@ -0,0 +1,30 @@
+package reportstore
+
+import (
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+//go:generate mockgen -destination=../mocks/reportstore/report_store.go -package=mocks github.com/ars0915/matching-system/internal/reportstore Store
+type (
+	Store interface {
+		ReportStoreIface
+	}
+)
+
+type (
+	ReportStoreIface interface {
+		// AddReport stores the report and assigns it the next ID unless it has one
+		AddReport(r *entity.Report) error
+		FindByID(id uint64) (*entity.Report, bool, error)
+		// List returns the reports of the status ordered by ID and the total number of them,
+		// an empty status lists every report
+		List(status constant.ReportStatus, offset, limit int) ([]entity.Report, int, error)
+		// Resolve closes the report with status
+		Resolve(id uint64, status constant.ReportStatus, at time.Time) error
+		// ResolveReported closes every open report on the person with status
+		ResolveReported(reportedID uint64, status constant.ReportStatus, at time.Time) error
+	}
+)
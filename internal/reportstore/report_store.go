@@ -0,0 +1,111 @@
+package reportstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+type ReportStore struct {
+	// reports are ordered by ID
+	reports []*entity.Report
+	idMap   map[uint64]*entity.Report
+	lastID  uint64
+	mu      sync.RWMutex
+}
+
+func NewReportStore() *ReportStore {
+	return &ReportStore{
+		idMap: map[uint64]*entity.Report{},
+	}
+}
+
+func (rs *ReportStore) AddReport(r *entity.Report) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	// Restored reports keep their ID
+	if r.ID == 0 {
+		r.ID = rs.lastID + 1
+	}
+	if r.ID > rs.lastID {
+		rs.lastID = r.ID
+	}
+
+	stored := *r
+	rs.reports = append(rs.reports, &stored)
+	rs.idMap[stored.ID] = &stored
+
+	return nil
+}
+
+func (rs *ReportStore) FindByID(id uint64) (*entity.Report, bool, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	r, exist := rs.idMap[id]
+	if !exist {
+		return nil, false, nil
+	}
+
+	report := *r
+	return &report, true, nil
+}
+
+func (rs *ReportStore) List(status constant.ReportStatus, offset, limit int) ([]entity.Report, int, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	var reports []entity.Report
+	for _, r := range rs.reports {
+		if status == "" || r.Status == status {
+			reports = append(reports, *r)
+		}
+	}
+	return page(reports, offset, limit), len(reports), nil
+}
+
+func (rs *ReportStore) Resolve(id uint64, status constant.ReportStatus, at time.Time) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if r, exist := rs.idMap[id]; exist {
+		resolve(r, status, at)
+	}
+	return nil
+}
+
+func (rs *ReportStore) ResolveReported(reportedID uint64, status constant.ReportStatus, at time.Time) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for _, r := range rs.reports {
+		if r.ReportedID == reportedID && r.Status == constant.ReportOpen {
+			resolve(r, status, at)
+		}
+	}
+	return nil
+}
+
+func resolve(r *entity.Report, status constant.ReportStatus, at time.Time) {
+	r.Status = status
+	r.ResolvedAt = &at
+}
+
+// page returns reports[offset:offset+limit], a negative limit means no limit
+func page(reports []entity.Report, offset, limit int) []entity.Report {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(reports) {
+		return nil
+	}
+
+	end := len(reports)
+	if limit >= 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return reports[offset:end]
+}
@@ -0,0 +1,100 @@
+package reportstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+type reportStoreTestSuite struct {
+	suite.Suite
+
+	rs *ReportStore
+}
+
+func Test_reportStoreTestSuite(t *testing.T) {
+	suite.Run(t, &reportStoreTestSuite{})
+}
+
+func (s *reportStoreTestSuite) SetupTest() {
+	s.rs = NewReportStore()
+
+	pairs := [][2]uint64{{1, 2}, {3, 2}, {1, 4}}
+	for _, pair := range pairs {
+		err := s.rs.AddReport(&entity.Report{
+			ReporterID: pair[0],
+			ReportedID: pair[1],
+			Reason:     "spam",
+			Status:     constant.ReportOpen,
+		})
+		assert.Nil(s.T(), err)
+	}
+}
+
+func (s *reportStoreTestSuite) Test_AddReport() {
+	r := entity.Report{ReporterID: 5, ReportedID: 6, Status: constant.ReportOpen}
+	assert.Nil(s.T(), s.rs.AddReport(&r))
+	assert.Equal(s.T(), uint64(4), r.ID)
+
+	got, exist, err := s.rs.FindByID(r.ID)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "report should be found")
+	assert.Equal(s.T(), r, *got)
+
+	// A restored report keeps its ID and later reports follow it
+	restored := entity.Report{ID: 10, ReporterID: 5, ReportedID: 7, Status: constant.ReportOpen}
+	assert.Nil(s.T(), s.rs.AddReport(&restored))
+	next := entity.Report{ReporterID: 5, ReportedID: 8, Status: constant.ReportOpen}
+	assert.Nil(s.T(), s.rs.AddReport(&next))
+	assert.Equal(s.T(), uint64(11), next.ID)
+}
+
+func (s *reportStoreTestSuite) Test_Resolve() {
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(s.T(), s.rs.Resolve(3, constant.ReportDismissed, at))
+	assert.Nil(s.T(), s.rs.ResolveReported(2, constant.ReportActioned, at))
+
+	tests := []struct {
+		name    string
+		status  constant.ReportStatus
+		wantIDs []uint64
+	}{
+		{"Open", constant.ReportOpen, nil},
+		{"Actioned", constant.ReportActioned, []uint64{1, 2}},
+		{"Dismissed", constant.ReportDismissed, []uint64{3}},
+		{"Every status", "", []uint64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			got, total, err := s.rs.List(tt.status, 0, paging.Unlimited)
+			assert.Nil(t, err)
+			assert.Equal(t, len(tt.wantIDs), total)
+
+			var ids []uint64
+			for _, r := range got {
+				ids = append(ids, r.ID)
+				assert.Equal(t, at, *r.ResolvedAt)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func (s *reportStoreTestSuite) Test_List() {
+	got, total, err := s.rs.List(constant.ReportOpen, 1, 1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 3, total)
+	if assert.Len(s.T(), got, 1) {
+		assert.Equal(s.T(), uint64(2), got[0].ID)
+	}
+
+	got, _, err = s.rs.List(constant.ReportOpen, 5, 1)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), got)
+}
@@ -0,0 +1,173 @@
+// Package snapshot saves and loads point-in-time copies of the in-memory person pools.
+package snapshot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
+	"github.com/ars0915/matching-system/internal/tree"
+)
+
+// Version is the file format version written by Save.
+// Version 1 kept boys and girls apart and is still loaded.
+const Version = 2
+
+var ErrorUnsupportedVersion = errors.New("unsupported snapshot version")
+
+type Snapshot struct {
+	Version      int
+	TakenAt      time.Time
+	LastPersonID uint64
+	// WALSeq is the sequence of the last write-ahead log record included
+	WALSeq  uint64
+	Persons []entity.Person
+	// Suspended are the IDs of the persons in Persons who are suspended
+	Suspended []uint64          `json:",omitempty"`
+	Reactions []entity.Reaction `json:",omitempty"`
+	Reports   []entity.Report   `json:",omitempty"`
+	// Matches are ordered by ID, matches are never removed so the next match ID follows the last one
+	Matches []entity.Match `json:",omitempty"`
+	// Activity is when each person last joined, liked or passed
+	Activity map[uint64]time.Time `json:",omitempty"`
+}
+
+// snapshotV1 holds the fields of version 1 that were replaced by Persons
+type snapshotV1 struct {
+	Boys  []entity.Person
+	Girls []entity.Person
+}
+
+// Info describes a saved snapshot
+type Info struct {
+	Path         string
+	Version      int
+	TakenAt      time.Time
+	LastPersonID uint64
+	WALSeq       uint64
+	PersonCount  int
+	MatchCount   int
+}
+
+func (s Snapshot) Info(path string) Info {
+	return Info{
+		Path:         path,
+		Version:      s.Version,
+		TakenAt:      s.TakenAt,
+		LastPersonID: s.LastPersonID,
+		WALSeq:       s.WALSeq,
+		PersonCount:  len(s.Persons),
+		MatchCount:   len(s.Matches),
+	}
+}
+
+// Save writes the snapshot to a temporary file and renames it over path,
+// so a crash while saving never leaves a half written snapshot behind
+func Save(path string, s Snapshot) error {
+	s.Version = Version
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "create temp snapshot")
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(s); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "encode snapshot")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "sync snapshot")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close snapshot")
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "rename snapshot")
+	}
+	return nil
+}
+
+// Load reads the snapshot at path, exist is false when there is no snapshot yet
+func Load(path string) (s Snapshot, exist bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, errors.Wrap(err, "read snapshot")
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Snapshot{}, false, errors.Wrap(err, "decode snapshot")
+	}
+
+	switch s.Version {
+	case Version:
+	case 1:
+		var v1 snapshotV1
+		if err := json.Unmarshal(data, &v1); err != nil {
+			return Snapshot{}, false, errors.Wrap(err, "decode snapshot")
+		}
+		for _, p := range append(v1.Boys, v1.Girls...) {
+			p.Seeking = entity.DefaultSeeking(p.Gender)
+			p.SeekingDefaulted = true
+			s.Persons = append(s.Persons, p)
+		}
+	default:
+		return Snapshot{}, false, errors.Wrapf(ErrorUnsupportedVersion, "version %d", s.Version)
+	}
+
+	return s, true, nil
+}
+
+// Restore adds the persons of the snapshot to the tree of their gender, suspending the
+// suspended ones, and the matches, reactions and reports to their stores
+func (s Snapshot) Restore(trees map[constant.Gender]tree.Tree, matches matchstore.Store, reactions reactionstore.Store, reports reportstore.Store) error {
+	suspended := make(map[uint64]bool, len(s.Suspended))
+	for _, id := range s.Suspended {
+		suspended[id] = true
+	}
+
+	for i := range s.Persons {
+		p := &s.Persons[i]
+		t, exist := trees[p.Gender]
+		if !exist {
+			return errors.Errorf("restore person %d: no tree for gender %q", p.ID, p.Gender)
+		}
+		if err := t.AddPerson(p); err != nil {
+			return errors.Wrapf(err, "restore person %d", p.ID)
+		}
+		if suspended[p.ID] {
+			if err := t.Suspend(p.ID); err != nil {
+				return errors.Wrapf(err, "suspend person %d", p.ID)
+			}
+		}
+	}
+	for i := range s.Matches {
+		if err := matches.AddMatch(&s.Matches[i]); err != nil {
+			return errors.Wrapf(err, "restore match %d", s.Matches[i].ID)
+		}
+	}
+	for _, r := range s.Reactions {
+		if err := reactions.Put(r); err != nil {
+			return errors.Wrapf(err, "restore reaction of person %d", r.PersonID)
+		}
+	}
+	for i := range s.Reports {
+		if err := reports.AddReport(&s.Reports[i]); err != nil {
+			return errors.Wrapf(err, "restore report %d", s.Reports[i].ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package snapshot
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/util/cTypes"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func Test_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	_, exist, err := Load(path)
+	assert.Nil(t, err)
+	assert.False(t, exist, "snapshot should not exist yet")
+
+	want := Snapshot{
+		TakenAt:      time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		LastPersonID: 3,
+		Persons: []entity.Person{
+			{
+				ID:          1,
+				Name:        "a",
+				Height:      180,
+				Gender:      constant.GenderMale,
+				Seeking:     []constant.Gender{constant.GenderFemale},
+				WantedDates: cTypes.Uint64(2),
+			},
+			{
+				ID:          2,
+				Name:        "b",
+				Height:      170,
+				Gender:      constant.GenderFemale,
+				Seeking:     []constant.Gender{constant.GenderMale},
+				WantedDates: cTypes.Uint64(1),
+			},
+			{
+				ID:          3,
+				Name:        "c",
+				Height:      160,
+				Gender:      constant.GenderNonBinary,
+				Seeking:     []constant.Gender{constant.GenderMale, constant.GenderNonBinary},
+				WantedDates: cTypes.Uint64(1),
+			},
+		},
+		Suspended: []uint64{2},
+		Reactions: []entity.Reaction{
+			{PersonID: 1, CandidateID: 3, Kind: constant.ReactionPass, CreatedAt: time.Date(2024, 6, 1, 11, 0, 0, 0, time.UTC)},
+		},
+		Reports: []entity.Report{
+			{ID: 4, ReporterID: 1, ReportedID: 2, Reason: "spam", Status: constant.ReportActioned, CreatedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		Matches: []entity.Match{
+			{ID: 5, PersonID1: 1, PersonID2: 3, Status: constant.MatchStatusMatched, CreatedAt: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)},
+		},
+		Activity: map[uint64]time.Time{1: time.Date(2024, 6, 1, 11, 0, 0, 0, time.UTC)},
+	}
+	assert.Nil(t, Save(path, want))
+
+	got, exist, err := Load(path)
+	assert.Nil(t, err)
+	assert.True(t, exist, "snapshot should exist")
+	want.Version = Version
+	assert.Equal(t, want, got)
+
+	trees, matches, reactions, reports := newTrees(), matchstore.NewMatchStore(), reactionstore.NewReactionStore(), reportstore.NewReportStore()
+	assert.Nil(t, got.Restore(trees, matches, reactions, reports))
+	assert.Equal(t, want.Persons[:1], trees[constant.GenderMale].QueryByHeight(0, math.MaxFloat64))
+	assert.Equal(t, want.Persons[2:], trees[constant.GenderNonBinary].QueryByHeight(0, math.MaxFloat64))
+	assert.Nil(t, trees[constant.GenderFemale].QueryByHeight(0, math.MaxFloat64), "suspended person should stay hidden")
+	assert.Equal(t, want.Persons[1:2], trees[constant.GenderFemale].Suspended())
+	gotReactions, err := reactions.List()
+	assert.Nil(t, err)
+	assert.Equal(t, want.Reactions, gotReactions)
+	gotReports, _, err := reports.List("", 0, paging.Unlimited)
+	assert.Nil(t, err)
+	assert.Equal(t, want.Reports, gotReports)
+	gotMatches, _, err := matches.List(0, paging.Unlimited)
+	assert.Nil(t, err)
+	assert.Equal(t, want.Matches, gotMatches)
+	next := entity.Match{PersonID1: 1, PersonID2: 2}
+	assert.Nil(t, matches.AddMatch(&next))
+	assert.Equal(t, uint64(6), next.ID, "the match IDs should carry on from the restored ones")
+}
+
+func Test_LoadVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	v1 := `{
+		"Version": 1,
+		"LastPersonID": 2,
+		"Boys": [{"ID": 1, "Name": "a", "Height": 180, "Gender": "male", "WantedDates": 2}],
+		"Girls": [{"ID": 2, "Name": "b", "Height": 160, "Gender": "female", "WantedDates": 1}]
+	}`
+	assert.Nil(t, os.WriteFile(path, []byte(v1), 0o644))
+
+	got, exist, err := Load(path)
+	assert.Nil(t, err)
+	assert.True(t, exist, "snapshot should exist")
+	assert.Equal(t, []entity.Person{
+		{ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, Seeking: []constant.Gender{constant.GenderFemale}, SeekingDefaulted: true, WantedDates: cTypes.Uint64(2)},
+		{ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, Seeking: []constant.Gender{constant.GenderMale}, SeekingDefaulted: true, WantedDates: cTypes.Uint64(1)},
+	}, got.Persons)
+	assert.Equal(t, 2, got.Info(path).PersonCount)
+}
+
+func newTrees() map[constant.Gender]tree.Tree {
+	trees := map[constant.Gender]tree.Tree{}
+	for _, g := range constant.Genders {
+		trees[g] = tree.NewPersonTree()
+	}
+	return trees
+}
+
+func Test_LoadUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"Version": 99}`), 0o644))
+
+	_, _, err := Load(path)
+	assert.ErrorIs(t, err, ErrorUnsupportedVersion)
+}
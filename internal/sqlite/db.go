@@ -0,0 +1,33 @@
+// Package sqlite persists persons and matches to a SQLite database.
+package sqlite
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/config"
+)
+
+// Open opens the database, call Migrate before using it
+func Open(conf config.SectionSQLite) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", conf.Database)
+	if err != nil {
+		return nil, errors.Wrap(err, "open sqlite")
+	}
+	if conf.MaxConn > 0 {
+		db.SetMaxOpenConns(conf.MaxConn)
+	}
+
+	return db, nil
+}
+
+// LastPersonID returns the largest person ID ever stored, including removed persons
+func LastPersonID(db *sql.DB) (uint64, error) {
+	var id uint64
+	if err := db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM persons`).Scan(&id); err != nil {
+		return 0, errors.Wrap(err, "query last person id")
+	}
+	return id, nil
+}
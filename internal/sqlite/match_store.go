@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"database/sql"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/entity"
+)
+
+type MatchStore struct {
+	db *sql.DB
+}
+
+func NewMatchStore(db *sql.DB) *MatchStore {
+	return &MatchStore{
+		db: db,
+	}
+}
+
+// AddMatch inserts the match and assigns it the generated ID
+func (ms *MatchStore) AddMatch(m *entity.Match) error {
+	return addMatch(ms.db, m)
+}
+
+// AddMatchWithDates saves the wanted dates of the persons and inserts the match in one transaction
+func (ms *MatchStore) AddMatchWithDates(m *entity.Match, persons ...*entity.Person) error {
+	return inTx(ms.db, func(tx *sql.Tx) error {
+		for _, p := range persons {
+			if _, err := tx.Exec(`UPDATE persons SET wanted_dates = ? WHERE id = ?`, atomic.LoadUint64(p.WantedDates), p.ID); err != nil {
+				return errors.Wrap(err, "update wanted dates")
+			}
+		}
+		return addMatch(tx, m)
+	})
+}
+
+// execer is a *sql.DB or a *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func addMatch(db execer, m *entity.Match) error {
+	result, err := db.Exec(
+		`INSERT INTO matches (person_id1, person_id2, status, created_at) VALUES (?, ?, ?, ?)`,
+		m.PersonID1, m.PersonID2, m.Status, m.CreatedAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert match")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "get match id")
+	}
+	m.ID = uint64(id)
+
+	return nil
+}
+
+func (ms *MatchStore) FindByID(id uint64) (*entity.Match, bool, error) {
+	var m entity.Match
+	err := ms.db.QueryRow(
+		`SELECT id, person_id1, person_id2, status, created_at FROM matches WHERE id = ?`, id,
+	).Scan(&m.ID, &m.PersonID1, &m.PersonID2, &m.Status, &m.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "query match")
+	}
+
+	return &m, true, nil
+}
+
+// List returns matches ordered by ID and the total number of matches, a negative limit means no limit
+func (ms *MatchStore) List(offset, limit int) ([]entity.Match, int, error) {
+	var total int
+	if err := ms.db.QueryRow(`SELECT COUNT(*) FROM matches`).Scan(&total); err != nil {
+		return nil, 0, errors.Wrap(err, "count matches")
+	}
+
+	matches, err := ms.query(
+		`SELECT id, person_id1, person_id2, status, created_at FROM matches ORDER BY id LIMIT ? OFFSET ?`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return matches, total, nil
+}
+
+// ListByPerson returns matches of the person ordered by ID and the total number of them
+func (ms *MatchStore) ListByPerson(personID uint64, offset, limit int) ([]entity.Match, int, error) {
+	var total int
+	err := ms.db.QueryRow(
+		`SELECT COUNT(*) FROM matches WHERE person_id1 = ? OR person_id2 = ?`, personID, personID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "count person matches")
+	}
+
+	matches, err := ms.query(
+		`SELECT id, person_id1, person_id2, status, created_at FROM matches
+		WHERE person_id1 = ? OR person_id2 = ? ORDER BY id LIMIT ? OFFSET ?`,
+		personID, personID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return matches, total, nil
+}
+
+func (ms *MatchStore) Matched(id1, id2 uint64) (bool, error) {
+	var matched bool
+	err := ms.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM matches
+		WHERE (person_id1 = ? AND person_id2 = ?) OR (person_id1 = ? AND person_id2 = ?))`,
+		id1, id2, id2, id1,
+	).Scan(&matched)
+	if err != nil {
+		return false, errors.Wrap(err, "query matched")
+	}
+
+	return matched, nil
+}
+
+func (ms *MatchStore) Partners(personID uint64) ([]uint64, error) {
+	rows, err := ms.db.Query(
+		`SELECT CASE WHEN person_id1 = ? THEN person_id2 ELSE person_id1 END FROM matches
+		WHERE person_id1 = ? OR person_id2 = ? ORDER BY id`,
+		personID, personID, personID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query partners")
+	}
+	defer rows.Close()
+
+	var partners []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "scan partner")
+		}
+		partners = append(partners, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate partners")
+	}
+
+	return partners, nil
+}
+
+func (ms *MatchStore) query(query string, args ...interface{}) ([]entity.Match, error) {
+	rows, err := ms.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query matches")
+	}
+	defer rows.Close()
+
+	var matches []entity.Match
+	for rows.Next() {
+		var m entity.Match
+		if err := rows.Scan(&m.ID, &m.PersonID1, &m.PersonID2, &m.Status, &m.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "scan match")
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate matches")
+	}
+
+	return matches, nil
+}
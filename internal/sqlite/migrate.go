@@ -0,0 +1,222 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// migrations are applied in order, append new ones to the end and never edit an applied one
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create persons",
+		up: `
+CREATE TABLE IF NOT EXISTS persons (
+	id           INTEGER PRIMARY KEY,
+	name         TEXT     NOT NULL,
+	height       REAL     NOT NULL,
+	gender       TEXT     NOT NULL,
+	wanted_dates INTEGER  NOT NULL,
+	removed_at   DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_persons_gender ON persons (gender, removed_at);
+`,
+		down: `DROP TABLE persons;`,
+	},
+	{
+		version: 2,
+		name:    "create matches",
+		up: `
+CREATE TABLE IF NOT EXISTS matches (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	person_id1 INTEGER  NOT NULL,
+	person_id2 INTEGER  NOT NULL,
+	status     TEXT     NOT NULL,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_matches_person_id1 ON matches (person_id1);
+CREATE INDEX IF NOT EXISTS idx_matches_person_id2 ON matches (person_id2);
+`,
+		down: `DROP TABLE matches;`,
+	},
+	{
+		version: 3,
+		name:    "add persons seeking",
+		// Comma separated genders, empty for persons created before seeking existed
+		up:   `ALTER TABLE persons ADD COLUMN seeking TEXT NOT NULL DEFAULT '';`,
+		down: `ALTER TABLE persons DROP COLUMN seeking;`,
+	},
+	{
+		version: 4,
+		name:    "create reactions",
+		up: `
+CREATE TABLE IF NOT EXISTS reactions (
+	person_id    INTEGER  NOT NULL,
+	candidate_id INTEGER  NOT NULL,
+	kind         TEXT     NOT NULL,
+	created_at   DATETIME NOT NULL,
+	PRIMARY KEY (person_id, candidate_id)
+);
+`,
+		down: `DROP TABLE reactions;`,
+	},
+	{
+		version: 5,
+		name:    "add persons attributes",
+		// Age 0 is unknown, tags are comma separated, no location is NULL
+		up: `
+ALTER TABLE persons ADD COLUMN age INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE persons ADD COLUMN city TEXT NOT NULL DEFAULT '';
+ALTER TABLE persons ADD COLUMN latitude REAL;
+ALTER TABLE persons ADD COLUMN longitude REAL;
+ALTER TABLE persons ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+`,
+		down: `
+ALTER TABLE persons DROP COLUMN tags;
+ALTER TABLE persons DROP COLUMN longitude;
+ALTER TABLE persons DROP COLUMN latitude;
+ALTER TABLE persons DROP COLUMN city;
+ALTER TABLE persons DROP COLUMN age;
+`,
+	},
+	{
+		version: 6,
+		name:    "create reports and suspend persons",
+		// Suspended persons stay out of the trees until suspended_at is cleared
+		up: `
+ALTER TABLE persons ADD COLUMN suspended_at DATETIME;
+CREATE TABLE IF NOT EXISTS reports (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	reporter_id INTEGER  NOT NULL,
+	reported_id INTEGER  NOT NULL,
+	reason      TEXT     NOT NULL,
+	status      TEXT     NOT NULL,
+	created_at  DATETIME NOT NULL,
+	resolved_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports (status);
+CREATE INDEX IF NOT EXISTS idx_reports_reported_id ON reports (reported_id);
+`,
+		down: `
+DROP TABLE reports;
+ALTER TABLE persons DROP COLUMN suspended_at;
+`,
+	},
+	{
+		version: 7,
+		name:    "add persons version",
+		up:      `ALTER TABLE persons ADD COLUMN version INTEGER NOT NULL DEFAULT 0;`,
+		down:    `ALTER TABLE persons DROP COLUMN version;`,
+	},
+}
+
+const migrationTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER  PRIMARY KEY,
+	name       TEXT     NOT NULL,
+	applied_at DATETIME NOT NULL
+);
+`
+
+// LatestVersion returns the version the schema reaches after all migrations are applied
+func LatestVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the version of the last applied migration, 0 if none
+func SchemaVersion(db *sql.DB) (int, error) {
+	if _, err := db.Exec(migrationTable); err != nil {
+		return 0, errors.Wrap(err, "create schema_migrations")
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, errors.Wrap(err, "query schema version")
+	}
+	return version, nil
+}
+
+// Migrate applies all pending migrations and returns how many were applied
+func Migrate(db *sql.DB) (int, error) {
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var applied int
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		err := inTx(db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(
+				`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now(),
+			)
+			return err
+		})
+		if err != nil {
+			return applied, errors.Wrapf(err, "migrate up to version %d", m.version)
+		}
+		applied++
+	}
+
+	return applied, nil
+}
+
+// Rollback reverts the last steps applied migrations and returns how many were reverted
+func Rollback(db *sql.DB, steps int) (int, error) {
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var reverted int
+	for i := len(migrations) - 1; i >= 0 && reverted < steps; i-- {
+		m := migrations[i]
+		if m.version > current {
+			continue
+		}
+
+		err := inTx(db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m.down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.version)
+			return err
+		})
+		if err != nil {
+			return reverted, errors.Wrapf(err, "rollback version %d", m.version)
+		}
+		reverted++
+	}
+
+	return reverted, nil
+}
+
+func inTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/config"
+)
+
+func Test_MigrateAndRollback(t *testing.T) {
+	db, err := Open(config.SectionSQLite{
+		Database: filepath.Join(t.TempDir(), "matching.db"),
+	})
+	assert.Nil(t, err)
+	defer db.Close()
+
+	version, err := SchemaVersion(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, version)
+
+	applied, err := Migrate(db)
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrations), applied)
+	assertVersion(t, db, LatestVersion())
+	assert.True(t, tableExists(t, db, "matches"))
+
+	applied, err = Migrate(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, applied, "migrate twice should apply nothing")
+
+	reverted, err := Rollback(db, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, reverted)
+	assertVersion(t, db, LatestVersion()-1)
+	assert.False(t, columnExists(t, db, "persons", "version"))
+	assert.True(t, columnExists(t, db, "persons", "suspended_at"))
+
+	reverted, err = Rollback(db, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrations)-1, reverted)
+	assertVersion(t, db, 0)
+	assert.False(t, tableExists(t, db, "persons"))
+
+	applied, err = Migrate(db)
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrations), applied)
+	assertVersion(t, db, LatestVersion())
+}
+
+func assertVersion(t *testing.T, db *sql.DB, want int) {
+	version, err := SchemaVersion(db)
+	assert.Nil(t, err)
+	assert.Equal(t, want, version)
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	assert.Nil(t, err)
+	return count > 0
+}
+
+func columnExists(t *testing.T, db *sql.DB, table, column string) bool {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	assert.Nil(t, err)
+	return count > 0
+}
@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+)
+
+// PersonTree writes every change to SQLite and keeps an in-memory tree.PersonTree as the query index
+type PersonTree struct {
+	*tree.PersonTree
+	db *sql.DB
+}
+
+// NewPersonTree rebuilds the index from the persons of the gender that have not been removed,
+// suspended persons are indexed as suspended
+func NewPersonTree(db *sql.DB, gender constant.Gender) (*PersonTree, error) {
+	pt := &PersonTree{
+		PersonTree: tree.NewPersonTree(),
+		db:         db,
+	}
+
+	rows, err := db.Query(
+		`SELECT id, name, height, gender, seeking, wanted_dates, age, city, latitude, longitude, tags, version,
+		suspended_at IS NOT NULL
+		FROM persons WHERE gender = ? AND removed_at IS NULL`,
+		gender,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query persons")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			seeking, tags       string
+			latitude, longitude sql.NullFloat64
+			suspended           bool
+		)
+		p := &entity.Person{WantedDates: new(uint64)}
+		err := rows.Scan(&p.ID, &p.Name, &p.Height, &p.Gender, &seeking, p.WantedDates, &p.Age, &p.City, &latitude, &longitude, &tags, &p.Version, &suspended)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan person")
+		}
+		if p.Seeking = splitGenders(seeking); len(p.Seeking) == 0 {
+			p.Seeking = entity.DefaultSeeking(p.Gender)
+			p.SeekingDefaulted = true
+		}
+		if latitude.Valid && longitude.Valid {
+			p.Location = &entity.Location{Latitude: latitude.Float64, Longitude: longitude.Float64}
+		}
+		if tags != "" {
+			p.Tags = strings.Split(tags, ",")
+		}
+		if err := pt.PersonTree.AddPerson(p); err != nil {
+			return nil, errors.Wrapf(err, "index person %d", p.ID)
+		}
+		if suspended {
+			if err := pt.PersonTree.Suspend(p.ID); err != nil {
+				return nil, errors.Wrapf(err, "suspend person %d", p.ID)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate persons")
+	}
+
+	return pt, nil
+}
+
+// insertPerson inserts a person, the row of a removed person with the same ID is reused
+// so a person whose gender changed can move from the tree of the old gender
+const insertPerson = `INSERT INTO persons (id, name, height, gender, seeking, wanted_dates, age, city, latitude, longitude, tags, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	name = excluded.name, height = excluded.height, gender = excluded.gender, seeking = excluded.seeking,
+	wanted_dates = excluded.wanted_dates, age = excluded.age, city = excluded.city,
+	latitude = excluded.latitude, longitude = excluded.longitude, tags = excluded.tags,
+	version = excluded.version, removed_at = NULL, suspended_at = NULL
+WHERE persons.removed_at IS NOT NULL`
+
+func insertArgs(p *entity.Person) []interface{} {
+	latitude, longitude := location(p)
+	return []interface{}{
+		p.ID, p.Name, p.Height, p.Gender, seekingColumn(p), atomic.LoadUint64(p.WantedDates),
+		p.Age, p.City, latitude, longitude, strings.Join(p.Tags, ","), p.Version,
+	}
+}
+
+func (pt *PersonTree) AddPerson(p *entity.Person) error {
+	if err := pt.PersonTree.AddPerson(p); err != nil {
+		return err
+	}
+
+	result, err := pt.db.Exec(insertPerson, insertArgs(p)...)
+	if err == nil {
+		err = insertedOne(result)
+	}
+	if err != nil {
+		// Keep the index in step with the database
+		_ = pt.PersonTree.RemovePerson(p.ID)
+		return errors.Wrap(err, "insert person")
+	}
+
+	return nil
+}
+
+// BulkAdd indexes the persons under one lock and inserts them in one transaction
+func (pt *PersonTree) BulkAdd(persons []*entity.Person) []error {
+	errs := pt.PersonTree.BulkAdd(persons)
+
+	// Keep the index in step with the database
+	fail := func(i int, err error) {
+		_ = pt.PersonTree.RemovePerson(persons[i].ID)
+		errs[i] = errors.Wrap(err, "insert person")
+	}
+	failRest := func(err error) {
+		for i := range persons {
+			if errs[i] == nil {
+				fail(i, err)
+			}
+		}
+	}
+
+	tx, err := pt.db.Begin()
+	if err != nil {
+		failRest(err)
+		return errs
+	}
+	for i, p := range persons {
+		if errs[i] != nil {
+			continue
+		}
+		result, err := tx.Exec(insertPerson, insertArgs(p)...)
+		if err == nil {
+			err = insertedOne(result)
+		}
+		if err != nil {
+			fail(i, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		failRest(err)
+	}
+
+	return errs
+}
+
+// insertedOne returns an error when the upsert skipped a row that is still in use
+func insertedOne(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return tree.ErrorPersonExist
+	}
+	return nil
+}
+
+func (pt *PersonTree) RemovePerson(id uint64) error {
+	p, suspended, err := pt.PersonTree.Take(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pt.db.Exec(`UPDATE persons SET removed_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		// Keep the index in step with the database
+		pt.PersonTree.Put(p, suspended)
+		return errors.Wrap(err, "remove person")
+	}
+
+	return nil
+}
+
+func (pt *PersonTree) UpdatePerson(p *entity.Person) error {
+	old, _ := pt.PersonTree.FindByID(p.ID)
+	if err := pt.PersonTree.UpdatePerson(p); err != nil {
+		return err
+	}
+
+	latitude, longitude := location(p)
+	_, err := pt.db.Exec(
+		`UPDATE persons SET name = ?, height = ?, seeking = ?, wanted_dates = ?, age = ?, city = ?,
+		latitude = ?, longitude = ?, tags = ?, version = ?
+		WHERE id = ?`,
+		p.Name, p.Height, seekingColumn(p), atomic.LoadUint64(p.WantedDates), p.Age, p.City,
+		latitude, longitude, strings.Join(p.Tags, ","), p.Version, p.ID,
+	)
+	if err != nil {
+		// Keep the index in step with the database
+		_ = pt.PersonTree.UpdatePerson(old)
+		return errors.Wrap(err, "update person")
+	}
+
+	return nil
+}
+
+func (pt *PersonTree) Suspend(id uint64) error {
+	if err := pt.PersonTree.Suspend(id); err != nil {
+		return err
+	}
+
+	if _, err := pt.db.Exec(`UPDATE persons SET suspended_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		// Keep the index in step with the database
+		_ = pt.PersonTree.Unsuspend(id)
+		return errors.Wrap(err, "suspend person")
+	}
+
+	return nil
+}
+
+func (pt *PersonTree) Unsuspend(id uint64) error {
+	if err := pt.PersonTree.Unsuspend(id); err != nil {
+		return err
+	}
+
+	if _, err := pt.db.Exec(`UPDATE persons SET suspended_at = NULL WHERE id = ?`, id); err != nil {
+		_ = pt.PersonTree.Suspend(id)
+		return errors.Wrap(err, "unsuspend person")
+	}
+
+	return nil
+}
+
+// location returns the coordinates of p, NULL when it has no location
+func location(p *entity.Person) (latitude, longitude sql.NullFloat64) {
+	if p.Location == nil {
+		return latitude, longitude
+	}
+	return sql.NullFloat64{Float64: p.Location.Latitude, Valid: true}, sql.NullFloat64{Float64: p.Location.Longitude, Valid: true}
+}
+
+// seekingColumn is empty for a derived seeking, which the index derives again on load
+func seekingColumn(p *entity.Person) string {
+	if p.SeekingDefaulted {
+		return ""
+	}
+	return joinGenders(p.Seeking)
+}
+
+func joinGenders(genders []constant.Gender) string {
+	names := make([]string, len(genders))
+	for i, g := range genders {
+		names[i] = string(g)
+	}
+	return strings.Join(names, ",")
+}
+
+func splitGenders(s string) []constant.Gender {
+	if s == "" {
+		return nil
+	}
+
+	var genders []constant.Gender
+	for _, name := range strings.Split(s, ",") {
+		genders = append(genders, constant.Gender(name))
+	}
+	return genders
+}
@@ -0,0 +1,413 @@
+package sqlite
+
+import (
+	"database/sql"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/ars0915/matching-system/config"
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/util/cTypes"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+type sqliteTestSuite struct {
+	suite.Suite
+
+	conf config.SectionSQLite
+	db   *sql.DB
+}
+
+func Test_sqliteTestSuite(t *testing.T) {
+	suite.Run(t, &sqliteTestSuite{})
+}
+
+func (s *sqliteTestSuite) SetupTest() {
+	s.conf = config.SectionSQLite{
+		Database: filepath.Join(s.T().TempDir(), "matching.db"),
+		MaxConn:  1,
+	}
+	s.db = s.open()
+}
+
+func (s *sqliteTestSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *sqliteTestSuite) open() *sql.DB {
+	db, err := Open(s.conf)
+	assert.Nil(s.T(), err)
+	_, err = Migrate(db)
+	assert.Nil(s.T(), err)
+	return db
+}
+
+// reopen closes the database and opens it again to simulate a restart
+func (s *sqliteTestSuite) reopen() {
+	s.db.Close()
+	s.db = s.open()
+}
+
+func (s *sqliteTestSuite) Test_PersonTreeSurvivesRestart() {
+	boys, err := NewPersonTree(s.db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+
+	seekingAll := []constant.Gender{constant.GenderMale, constant.GenderFemale, constant.GenderNonBinary}
+	seekingMale := []constant.Gender{constant.GenderMale}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 170, Gender: constant.GenderMale, Seeking: seekingAll, WantedDates: cTypes.Uint64(2)},
+		{ID: 2, Name: "b", Height: 180, Gender: constant.GenderMale, Seeking: seekingAll, WantedDates: cTypes.Uint64(1)},
+		{ID: 3, Name: "c", Height: 175, Gender: constant.GenderMale, Seeking: seekingMale, WantedDates: cTypes.Uint64(3),
+			Age: 30, City: "Taipei", Location: &entity.Location{Latitude: 25.033, Longitude: 121.565}, Tags: []string{"jazz", "hiking"}},
+	}
+	for i := range people {
+		assert.Nil(s.T(), boys.AddPerson(&people[i]))
+	}
+
+	*people[0].WantedDates = 1
+	people[0].City = "Tainan"
+	assert.Nil(s.T(), boys.UpdatePerson(&people[0]))
+	assert.Nil(s.T(), boys.RemovePerson(people[1].ID))
+
+	s.reopen()
+
+	boys, err = NewPersonTree(s.db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+
+	got := boys.QueryByHeight(0, math.MaxFloat64)
+	assert.Equal(s.T(), []entity.Person{people[0], people[2]}, got)
+
+	girls, err := NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), girls.QueryByHeight(0, math.MaxFloat64))
+
+	lastID, err := LastPersonID(s.db)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(3), lastID, "removed persons should still count")
+}
+
+func (s *sqliteTestSuite) Test_PersonTreeBulkAddSurvivesRestart() {
+	girls, err := NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+
+	seeking := []constant.Gender{constant.GenderMale}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 160, Gender: constant.GenderFemale, Seeking: seeking, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "b", Height: 165, Gender: constant.GenderFemale, Seeking: seeking, WantedDates: cTypes.Uint64(2)},
+		{ID: 1, Name: "c", Height: 170, Gender: constant.GenderFemale, Seeking: seeking, WantedDates: cTypes.Uint64(1)},
+	}
+	errs := girls.BulkAdd([]*entity.Person{&people[0], &people[1], &people[2]})
+	assert.Nil(s.T(), errs[0])
+	assert.Nil(s.T(), errs[1])
+	assert.Equal(s.T(), tree.ErrorPersonExist, errs[2])
+
+	s.reopen()
+
+	girls, err = NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), people[:2], girls.QueryByHeight(0, math.MaxFloat64))
+}
+
+func (s *sqliteTestSuite) Test_PersonTreeDefaultSeeking() {
+	// Rows written before the seeking column existed have it empty
+	_, err := s.db.Exec(`INSERT INTO persons (id, name, height, gender, wanted_dates) VALUES (1, 'a', 160, 'female', 1)`)
+	assert.Nil(s.T(), err)
+
+	girls, err := NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+
+	p, exist := girls.FindByID(1)
+	assert.True(s.T(), exist, "person should be loaded")
+	assert.Equal(s.T(), []constant.Gender{constant.GenderMale}, p.Seeking)
+	assert.True(s.T(), p.SeekingDefaulted)
+
+	// A derived seeking is stored empty, so it is still derived after a restart
+	derived := entity.Person{ID: 2, Name: "b", Height: 165, Gender: constant.GenderFemale,
+		Seeking: []constant.Gender{constant.GenderMale}, SeekingDefaulted: true, WantedDates: cTypes.Uint64(1)}
+	assert.Nil(s.T(), girls.AddPerson(&derived))
+	s.reopen()
+	girls, err = NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	p, _ = girls.FindByID(2)
+	assert.Equal(s.T(), derived, *p)
+}
+
+func (s *sqliteTestSuite) Test_PersonTreeGenderChangeSurvivesRestart() {
+	boys, err := NewPersonTree(s.db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+	girls, err := NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+
+	boy := entity.Person{ID: 1, Name: "a", Height: 170, Gender: constant.GenderMale, Seeking: []constant.Gender{constant.GenderFemale}, WantedDates: cTypes.Uint64(2)}
+	assert.Nil(s.T(), boys.AddPerson(&boy))
+	assert.Equal(s.T(), tree.ErrorPersonExist, errors.Cause(girls.AddPerson(&entity.Person{ID: 1, WantedDates: cTypes.Uint64(1)})),
+		"the row of a person still in use should not be taken over")
+
+	// A gender change removes the person from one tree and adds it to the other
+	moved := boy
+	moved.Gender, moved.Height, moved.Seeking, moved.Version = constant.GenderFemale, 160, []constant.Gender{constant.GenderMale}, 1
+	assert.Nil(s.T(), boys.RemovePerson(boy.ID))
+	assert.Nil(s.T(), girls.AddPerson(&moved))
+
+	s.reopen()
+
+	boys, err = NewPersonTree(s.db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), boys.QueryByHeight(0, math.MaxFloat64))
+	girls, err = NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Person{moved}, girls.QueryByHeight(0, math.MaxFloat64))
+}
+
+func (s *sqliteTestSuite) Test_PersonTreeSuspensionSurvivesRestart() {
+	girls, err := NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 160, Gender: constant.GenderFemale, Seeking: []constant.Gender{constant.GenderMale}, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "b", Height: 165, Gender: constant.GenderFemale, Seeking: []constant.Gender{constant.GenderMale}, WantedDates: cTypes.Uint64(1)},
+		{ID: 3, Name: "c", Height: 170, Gender: constant.GenderFemale, Seeking: []constant.Gender{constant.GenderMale}, WantedDates: cTypes.Uint64(1)},
+	}
+	for i := range people {
+		assert.Nil(s.T(), girls.AddPerson(&people[i]))
+	}
+	assert.Nil(s.T(), girls.Suspend(1))
+	assert.Nil(s.T(), girls.Suspend(2))
+	assert.Nil(s.T(), girls.Unsuspend(2))
+
+	s.reopen()
+
+	girls, err = NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), people[1:], girls.QueryByHeight(0, math.MaxFloat64), "suspended person should stay hidden")
+	assert.Equal(s.T(), people[:1], girls.Suspended())
+
+	// Unsuspending restores the person after another restart
+	assert.Nil(s.T(), girls.Unsuspend(1))
+	s.reopen()
+	girls, err = NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), people, girls.QueryByHeight(0, math.MaxFloat64))
+}
+
+func (s *sqliteTestSuite) Test_MatchStoreSurvivesRestart() {
+	ms := NewMatchStore(s.db)
+
+	createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	pairs := [][2]uint64{{1, 2}, {1, 3}, {4, 2}}
+	for _, pair := range pairs {
+		err := ms.AddMatch(&entity.Match{
+			PersonID1: pair[0],
+			PersonID2: pair[1],
+			Status:    constant.MatchStatusMatched,
+			CreatedAt: createdAt,
+		})
+		assert.Nil(s.T(), err)
+	}
+
+	s.reopen()
+	ms = NewMatchStore(s.db)
+
+	m, exist, err := ms.FindByID(2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "match should be found")
+	assert.Equal(s.T(), entity.Match{
+		ID:        2,
+		PersonID1: 1,
+		PersonID2: 3,
+		Status:    constant.MatchStatusMatched,
+		CreatedAt: createdAt,
+	}, *m)
+
+	matches, total, err := ms.List(1, 1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 3, total)
+	assert.Equal(s.T(), uint64(2), matches[0].ID)
+
+	matches, total, err = ms.ListByPerson(2, 0, paging.Unlimited)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, total)
+	assert.Equal(s.T(), uint64(1), matches[0].ID)
+	assert.Equal(s.T(), uint64(3), matches[1].ID)
+
+	matched, err := ms.Matched(2, 4)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), matched, "the order of the pair should not matter")
+	matched, err = ms.Matched(2, 3)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), matched)
+
+	partners, err := ms.Partners(2)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{1, 4}, partners)
+}
+
+func (s *sqliteTestSuite) Test_ReactionStoreSurvivesRestart() {
+	rs := NewReactionStore(s.db)
+
+	createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reactions := []entity.Reaction{
+		{PersonID: 1, CandidateID: 2, Kind: constant.ReactionLike, CreatedAt: createdAt},
+		{PersonID: 1, CandidateID: 3, Kind: constant.ReactionLike, CreatedAt: createdAt},
+		{PersonID: 2, CandidateID: 1, Kind: constant.ReactionLike, CreatedAt: createdAt},
+	}
+	for _, r := range reactions {
+		assert.Nil(s.T(), rs.Put(r))
+	}
+	// A pass replaces the earlier like
+	reactions[1].Kind = constant.ReactionPass
+	assert.Nil(s.T(), rs.Put(reactions[1]))
+	assert.Nil(s.T(), rs.Delete(2, 1))
+	blocks := []entity.Reaction{
+		{PersonID: 1, CandidateID: 4, Kind: constant.ReactionBlock, CreatedAt: createdAt},
+		{PersonID: 5, CandidateID: 4, Kind: constant.ReactionBlock, CreatedAt: createdAt},
+	}
+	for _, r := range blocks {
+		assert.Nil(s.T(), rs.Put(r))
+	}
+
+	s.reopen()
+	rs = NewReactionStore(s.db)
+
+	got, exist, err := rs.Find(1, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "reaction should be found")
+	assert.Equal(s.T(), reactions[0], *got)
+
+	_, exist, err = rs.Find(2, 1)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), exist, "reaction should be deleted")
+
+	passed, err := rs.Passed(1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{3}, passed)
+
+	blocked, err := rs.Blocked(4)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []uint64{1, 5}, blocked, "blocks of both directions should be listed")
+
+	all, err := rs.List()
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), append(reactions[:2:2], blocks...), all)
+}
+
+func (s *sqliteTestSuite) Test_ReportStoreSurvivesRestart() {
+	rs := NewReportStore(s.db)
+
+	createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	pairs := [][2]uint64{{1, 2}, {3, 2}, {1, 4}}
+	for _, pair := range pairs {
+		err := rs.AddReport(&entity.Report{
+			ReporterID: pair[0],
+			ReportedID: pair[1],
+			Reason:     "spam",
+			Status:     constant.ReportOpen,
+			CreatedAt:  createdAt,
+		})
+		assert.Nil(s.T(), err)
+	}
+	resolvedAt := createdAt.Add(time.Hour)
+	assert.Nil(s.T(), rs.ResolveReported(2, constant.ReportActioned, resolvedAt))
+
+	s.reopen()
+	rs = NewReportStore(s.db)
+
+	r, exist, err := rs.FindByID(2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "report should be found")
+	assert.Equal(s.T(), entity.Report{
+		ID:         2,
+		ReporterID: 3,
+		ReportedID: 2,
+		Reason:     "spam",
+		Status:     constant.ReportActioned,
+		CreatedAt:  createdAt,
+		ResolvedAt: &resolvedAt,
+	}, *r)
+
+	open, total, err := rs.List(constant.ReportOpen, 0, paging.Unlimited)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+	assert.Equal(s.T(), uint64(3), open[0].ID)
+	assert.Nil(s.T(), open[0].ResolvedAt)
+
+	_, total, err = rs.List("", 1, 1)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 3, total)
+}
+
+func (s *sqliteTestSuite) Test_PersonTreeRollback() {
+	db := s.open()
+	boys, err := NewPersonTree(db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+
+	seeking := []constant.Gender{constant.GenderFemale}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 170, Gender: constant.GenderMale, Seeking: seeking, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "b", Height: 180, Gender: constant.GenderMale, Seeking: seeking, WantedDates: cTypes.Uint64(1)},
+	}
+	for i := range people {
+		assert.Nil(s.T(), boys.AddPerson(&people[i]))
+	}
+	assert.Nil(s.T(), boys.Suspend(people[1].ID))
+
+	// Every write fails once the database is closed, the index should stay as it was
+	db.Close()
+
+	updated := people[0]
+	updated.Height = 190
+	assert.NotNil(s.T(), boys.UpdatePerson(&updated))
+	assert.Equal(s.T(), people[:1], boys.QueryByHeight(0, math.MaxFloat64))
+
+	assert.NotNil(s.T(), boys.RemovePerson(people[0].ID))
+	_, exist := boys.FindByID(people[0].ID)
+	assert.True(s.T(), exist, "the person should stay indexed")
+	assert.Equal(s.T(), people[:1], boys.QueryByHeight(0, math.MaxFloat64))
+
+	assert.NotNil(s.T(), boys.RemovePerson(people[1].ID))
+	assert.Equal(s.T(), people[1:], boys.Suspended(), "the person should stay suspended")
+}
+
+func (s *sqliteTestSuite) Test_MatchStoreAddMatchWithDates() {
+	boys, err := NewPersonTree(s.db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+	girls, err := NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	boy := entity.Person{ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(2)}
+	girl := entity.Person{ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(2)}
+	assert.Nil(s.T(), boys.AddPerson(&boy))
+	assert.Nil(s.T(), girls.AddPerson(&girl))
+
+	ms := NewMatchStore(s.db)
+	*boy.WantedDates, *girl.WantedDates = 1, 1
+	m := entity.Match{PersonID1: 1, PersonID2: 2, Status: constant.MatchStatusMatched, CreatedAt: time.Now()}
+	assert.Nil(s.T(), ms.AddMatchWithDates(&m, &boy, &girl))
+	assert.Equal(s.T(), uint64(1), m.ID)
+
+	// A failed match insert should leave the dates as they were
+	_, err = s.db.Exec(`CREATE TRIGGER no_matches BEFORE INSERT ON matches BEGIN SELECT RAISE(ABORT, 'no matches'); END`)
+	assert.Nil(s.T(), err)
+	*boy.WantedDates, *girl.WantedDates = 0, 0
+	assert.NotNil(s.T(), ms.AddMatchWithDates(&entity.Match{PersonID1: 1, PersonID2: 2}, &boy, &girl))
+
+	s.reopen()
+	boys, err = NewPersonTree(s.db, constant.GenderMale)
+	assert.Nil(s.T(), err)
+	girls, err = NewPersonTree(s.db, constant.GenderFemale)
+	assert.Nil(s.T(), err)
+	p, _ := boys.FindByID(1)
+	assert.Equal(s.T(), uint64(1), *p.WantedDates)
+	p, _ = girls.FindByID(2)
+	assert.Equal(s.T(), uint64(1), *p.WantedDates)
+	_, total, err := NewMatchStore(s.db).List(0, paging.Unlimited)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, total)
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+type ReactionStore struct {
+	db *sql.DB
+}
+
+func NewReactionStore(db *sql.DB) *ReactionStore {
+	return &ReactionStore{
+		db: db,
+	}
+}
+
+func (rs *ReactionStore) Put(r entity.Reaction) error {
+	_, err := rs.db.Exec(
+		`INSERT INTO reactions (person_id, candidate_id, kind, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (person_id, candidate_id) DO UPDATE SET kind = excluded.kind, created_at = excluded.created_at`,
+		r.PersonID, r.CandidateID, r.Kind, r.CreatedAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "put reaction")
+	}
+	return nil
+}
+
+func (rs *ReactionStore) Find(personID, candidateID uint64) (*entity.Reaction, bool, error) {
+	var r entity.Reaction
+	err := rs.db.QueryRow(
+		`SELECT person_id, candidate_id, kind, created_at FROM reactions WHERE person_id = ? AND candidate_id = ?`,
+		personID, candidateID,
+	).Scan(&r.PersonID, &r.CandidateID, &r.Kind, &r.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "query reaction")
+	}
+
+	return &r, true, nil
+}
+
+func (rs *ReactionStore) Delete(personID, candidateID uint64) error {
+	_, err := rs.db.Exec(`DELETE FROM reactions WHERE person_id = ? AND candidate_id = ?`, personID, candidateID)
+	if err != nil {
+		return errors.Wrap(err, "delete reaction")
+	}
+	return nil
+}
+
+func (rs *ReactionStore) Passed(personID uint64) ([]uint64, error) {
+	rows, err := rs.db.Query(
+		`SELECT candidate_id FROM reactions WHERE person_id = ? AND kind = ? ORDER BY candidate_id`,
+		personID, constant.ReactionPass,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query passes")
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "scan pass")
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate passes")
+	}
+
+	return ids, nil
+}
+
+func (rs *ReactionStore) Blocked(personID uint64) ([]uint64, error) {
+	rows, err := rs.db.Query(
+		`SELECT candidate_id FROM reactions WHERE person_id = ? AND kind = ?
+		UNION
+		SELECT person_id FROM reactions WHERE candidate_id = ? AND kind = ?
+		ORDER BY 1`,
+		personID, constant.ReactionBlock, personID, constant.ReactionBlock,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query blocks")
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "scan block")
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate blocks")
+	}
+
+	return ids, nil
+}
+
+func (rs *ReactionStore) List() ([]entity.Reaction, error) {
+	rows, err := rs.db.Query(
+		`SELECT person_id, candidate_id, kind, created_at FROM reactions ORDER BY person_id, candidate_id`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query reactions")
+	}
+	defer rows.Close()
+
+	var reactions []entity.Reaction
+	for rows.Next() {
+		var r entity.Reaction
+		if err := rows.Scan(&r.PersonID, &r.CandidateID, &r.Kind, &r.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "scan reaction")
+		}
+		reactions = append(reactions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate reactions")
+	}
+
+	return reactions, nil
+}
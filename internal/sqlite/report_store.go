@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+type ReportStore struct {
+	db *sql.DB
+}
+
+func NewReportStore(db *sql.DB) *ReportStore {
+	return &ReportStore{
+		db: db,
+	}
+}
+
+// AddReport inserts the report and assigns it the generated ID unless it has one
+func (rs *ReportStore) AddReport(r *entity.Report) error {
+	id := sql.NullInt64{Int64: int64(r.ID), Valid: r.ID != 0}
+	result, err := rs.db.Exec(
+		`INSERT INTO reports (id, reporter_id, reported_id, reason, status, created_at, resolved_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		id, r.ReporterID, r.ReportedID, r.Reason, r.Status, r.CreatedAt, r.ResolvedAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert report")
+	}
+
+	inserted, err := result.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "get report id")
+	}
+	r.ID = uint64(inserted)
+
+	return nil
+}
+
+func (rs *ReportStore) FindByID(id uint64) (*entity.Report, bool, error) {
+	reports, err := rs.query(`SELECT `+reportColumns+` FROM reports WHERE id = ?`, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(reports) == 0 {
+		return nil, false, nil
+	}
+
+	return &reports[0], true, nil
+}
+
+// List returns the reports of the status ordered by ID and the total number of them, a negative limit means no limit
+func (rs *ReportStore) List(status constant.ReportStatus, offset, limit int) ([]entity.Report, int, error) {
+	var total int
+	err := rs.db.QueryRow(`SELECT COUNT(*) FROM reports WHERE ? = '' OR status = ?`, status, status).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "count reports")
+	}
+
+	reports, err := rs.query(
+		`SELECT `+reportColumns+` FROM reports WHERE ? = '' OR status = ? ORDER BY id LIMIT ? OFFSET ?`,
+		status, status, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+func (rs *ReportStore) Resolve(id uint64, status constant.ReportStatus, at time.Time) error {
+	_, err := rs.db.Exec(`UPDATE reports SET status = ?, resolved_at = ? WHERE id = ?`, status, at, id)
+	if err != nil {
+		return errors.Wrap(err, "resolve report")
+	}
+	return nil
+}
+
+func (rs *ReportStore) ResolveReported(reportedID uint64, status constant.ReportStatus, at time.Time) error {
+	_, err := rs.db.Exec(
+		`UPDATE reports SET status = ?, resolved_at = ? WHERE reported_id = ? AND status = ?`,
+		status, at, reportedID, constant.ReportOpen,
+	)
+	if err != nil {
+		return errors.Wrap(err, "resolve reports")
+	}
+	return nil
+}
+
+const reportColumns = `id, reporter_id, reported_id, reason, status, created_at, resolved_at`
+
+func (rs *ReportStore) query(query string, args ...interface{}) ([]entity.Report, error) {
+	rows, err := rs.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query reports")
+	}
+	defer rows.Close()
+
+	var reports []entity.Report
+	for rows.Next() {
+		var (
+			r          entity.Report
+			resolvedAt sql.NullTime
+		)
+		err := rows.Scan(&r.ID, &r.ReporterID, &r.ReportedID, &r.Reason, &r.Status, &r.CreatedAt, &resolvedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan report")
+		}
+		if resolvedAt.Valid {
+			r.ResolvedAt = &resolvedAt.Time
+		}
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate reports")
+	}
+
+	return reports, nil
+}
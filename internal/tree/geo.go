@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"math"
+
+	"github.com/ars0915/matching-system/entity"
+)
+
+const (
+	// geoCellDegrees is the side of a grid cell, about 11 km of latitude
+	geoCellDegrees = 0.1
+	// maxGeoCells caps the cells a radius query visits, a wider radius walks the height range instead
+	maxGeoCells = 4096
+
+	kmPerDegree = 111.32
+)
+
+// lngCells is the number of cells around a circle of latitude
+var lngCells = int(math.Round(360 / geoCellDegrees))
+
+// geoCell is a cell of a fixed latitude and longitude grid
+type geoCell struct {
+	lat int
+	lng int
+}
+
+func cellOf(l entity.Location) geoCell {
+	return geoCell{
+		lat: int(math.Floor(l.Latitude / geoCellDegrees)),
+		lng: wrapLng(int(math.Floor(l.Longitude / geoCellDegrees))),
+	}
+}
+
+// wrapLng folds a longitude cell index into [0, lngCells) so the antimeridian has no seam
+func wrapLng(i int) int {
+	return ((i % lngCells) + lngCells) % lngCells
+}
+
+// cellsWithin returns the cells of the bounding box of the circle of radiusKm
+// around center, false when there are more than maxGeoCells of them
+func cellsWithin(center entity.Location, radiusKm float64) ([]geoCell, bool) {
+	latDelta := radiusKm / kmPerDegree
+	minLat := math.Max(-90, center.Latitude-latDelta)
+	maxLat := math.Min(90, center.Latitude+latDelta)
+
+	// A degree of longitude shrinks toward the poles, the widest circle of latitude in the box decides
+	widest := math.Max(math.Abs(minLat), math.Abs(maxLat))
+	lngDelta := 180.0
+	if cos := math.Cos(widest * math.Pi / 180); cos > 0 {
+		lngDelta = math.Min(180, radiusKm/(kmPerDegree*cos))
+	}
+
+	latFrom, latTo := int(math.Floor(minLat/geoCellDegrees)), int(math.Floor(maxLat/geoCellDegrees))
+	lngFrom, lngTo := int(math.Floor((center.Longitude-lngDelta)/geoCellDegrees)), int(math.Floor((center.Longitude+lngDelta)/geoCellDegrees))
+	if lngTo-lngFrom+1 > lngCells {
+		lngFrom, lngTo = 0, lngCells-1
+	}
+
+	if (latTo-latFrom+1)*(lngTo-lngFrom+1) > maxGeoCells {
+		return nil, false
+	}
+
+	var cells []geoCell
+	for lat := latFrom; lat <= latTo; lat++ {
+		for lng := lngFrom; lng <= lngTo; lng++ {
+			cells = append(cells, geoCell{lat: lat, lng: wrapLng(lng)})
+		}
+	}
+	return cells, true
+}
@@ -0,0 +1,68 @@
+package tree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_cellsWithin(t *testing.T) {
+	cells, ok := cellsWithin(entity.Location{Latitude: 25.03, Longitude: 121.56}, 20)
+	assert.True(t, ok)
+	assert.Contains(t, cells, cellOf(entity.Location{Latitude: 25.2, Longitude: 121.56}), "20 km north")
+	assert.Contains(t, cells, cellOf(entity.Location{Latitude: 25.03, Longitude: 121.75}), "19 km east")
+	assert.NotContains(t, cells, cellOf(entity.Location{Latitude: 25.5, Longitude: 121.56}), "52 km north")
+
+	// The box wraps around the antimeridian
+	cells, ok = cellsWithin(entity.Location{Latitude: 0, Longitude: 179.99}, 5)
+	assert.True(t, ok)
+	assert.Contains(t, cells, cellOf(entity.Location{Latitude: 0, Longitude: -179.99}))
+
+	_, ok = cellsWithin(entity.Location{Latitude: 0, Longitude: 0}, 5000)
+	assert.False(t, ok, "a radius this wide should not use the grid")
+
+	_, ok = cellsWithin(entity.Location{Latitude: 89.99, Longitude: 0}, 1)
+	assert.True(t, ok, "near the pole the box covers every longitude without overflowing")
+}
+
+func Test_QueryRadiusMatchesScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	center := entity.Location{Latitude: 25.03, Longitude: 121.56}
+
+	pt := NewPersonTree()
+	for id := uint64(1); id <= 500; id++ {
+		p := &entity.Person{ID: id, Height: 150 + float64(rnd.Intn(40)), WantedDates: cTypes.Uint64(1)}
+		// Most people are far away, some have no location at all
+		if id%10 != 0 {
+			p.Location = &entity.Location{
+				Latitude:  center.Latitude + (rnd.Float64()-0.5)*4,
+				Longitude: center.Longitude + (rnd.Float64()-0.5)*4,
+			}
+		}
+		assert.Nil(t, pt.AddPerson(p))
+	}
+	for id := uint64(1); id <= 500; id += 13 {
+		assert.Nil(t, pt.RemovePerson(id))
+	}
+
+	for _, radius := range []float64{5, 20, 50} {
+		q := Query{MinHeight: 165, MaxHeight: 185, Filter: Filter{Near: &center, MaxDistanceKm: radius}}
+
+		_, ok := pt.attrs.lookup(q.Filter, len(pt.idMap))
+		assert.True(t, ok, "radius %v should use the grid", radius)
+
+		var want []entity.Person
+		for _, p := range pt.Snapshot() {
+			if p.Height >= q.MinHeight && p.Height <= q.MaxHeight && p.Location != nil && p.Location.DistanceKm(center) <= radius {
+				want = append(want, p)
+			}
+		}
+		sort.Slice(want, func(i, j int) bool { return shortestFirst(want[i], want[j]) })
+		assert.Equal(t, want, pt.QueryOrdered(q, false, len(want)+1), "radius %v", radius)
+	}
+}
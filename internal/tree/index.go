@@ -0,0 +1,210 @@
+package tree
+
+import (
+	"strings"
+
+	"github.com/ars0915/matching-system/entity"
+)
+
+// indexScanRatio decides when a secondary index is worth it. The index is used
+// when it narrows the persons down to at most 1/indexScanRatio of the tree,
+// otherwise walking the height range and stopping at k is cheaper.
+const indexScanRatio = 4
+
+// Filter narrows a query down by the attributes of the persons, zero fields do not filter
+type Filter struct {
+	// MinAge and MaxAge bound the age, persons of unknown age are left out when set
+	MinAge int
+	MaxAge int
+	// City keeps the persons of the city, case insensitive
+	City string
+	// Tags keeps the persons with at least one of the tags
+	Tags []string
+	// Near keeps the persons with a location within MaxDistanceKm of it
+	Near          *entity.Location
+	MaxDistanceKm float64
+}
+
+// Match reports whether p passes the filter
+func (f Filter) Match(p entity.Person) bool {
+	if (f.MinAge > 0 || f.MaxAge > 0) && p.Age == 0 {
+		return false
+	}
+	if f.MinAge > 0 && p.Age < f.MinAge {
+		return false
+	}
+	if f.MaxAge > 0 && p.Age > f.MaxAge {
+		return false
+	}
+	if f.City != "" && !strings.EqualFold(p.City, f.City) {
+		return false
+	}
+	if len(f.Tags) > 0 && !p.HasAnyTag(f.Tags) {
+		return false
+	}
+	if f.Near != nil && (p.Location == nil || p.Location.DistanceKm(*f.Near) > f.MaxDistanceKm) {
+		return false
+	}
+	return true
+}
+
+// idSet is the ids of the persons sharing an attribute value
+type idSet map[uint64]struct{}
+
+// attrIndex maps each attribute value to the persons having it
+type attrIndex[K comparable] map[K]idSet
+
+func (idx attrIndex[K]) add(key K, id uint64) {
+	ids, exist := idx[key]
+	if !exist {
+		ids = idSet{}
+		idx[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (idx attrIndex[K]) remove(key K, id uint64) {
+	ids, exist := idx[key]
+	if !exist {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(idx, key)
+	}
+}
+
+// indexedAttrs is what a person was indexed under, kept apart from the person
+// because callers may change the stored person in place before updating it
+type indexedAttrs struct {
+	age  int
+	city string
+	tags []string
+	// cell is nil for persons without a location
+	cell *geoCell
+}
+
+// attrIndexes are the secondary indexes of a PersonTree, byCell is a grid
+// over the locations for radius queries
+type attrIndexes struct {
+	byAge  attrIndex[int]
+	byCity attrIndex[string]
+	byTag  attrIndex[string]
+	byCell attrIndex[geoCell]
+	attrs  map[uint64]indexedAttrs
+}
+
+func newAttrIndexes() attrIndexes {
+	return attrIndexes{
+		byAge:  attrIndex[int]{},
+		byCity: attrIndex[string]{},
+		byTag:  attrIndex[string]{},
+		byCell: attrIndex[geoCell]{},
+		attrs:  map[uint64]indexedAttrs{},
+	}
+}
+
+func (ai attrIndexes) add(p *entity.Person) {
+	attrs := indexedAttrs{
+		age:  p.Age,
+		city: strings.ToLower(p.City),
+		tags: append([]string(nil), p.Tags...),
+	}
+	if attrs.age > 0 {
+		ai.byAge.add(attrs.age, p.ID)
+	}
+	if attrs.city != "" {
+		ai.byCity.add(attrs.city, p.ID)
+	}
+	for _, tag := range attrs.tags {
+		ai.byTag.add(tag, p.ID)
+	}
+	if p.Location != nil {
+		cell := cellOf(*p.Location)
+		attrs.cell = &cell
+		ai.byCell.add(cell, p.ID)
+	}
+	ai.attrs[p.ID] = attrs
+}
+
+func (ai attrIndexes) remove(id uint64) {
+	attrs, exist := ai.attrs[id]
+	if !exist {
+		return
+	}
+	ai.byAge.remove(attrs.age, id)
+	ai.byCity.remove(attrs.city, id)
+	for _, tag := range attrs.tags {
+		ai.byTag.remove(tag, id)
+	}
+	if attrs.cell != nil {
+		ai.byCell.remove(*attrs.cell, id)
+	}
+	delete(ai.attrs, id)
+}
+
+// lookup returns the ids of the smallest index set matching f, false when f uses
+// no index or no index is selective enough among total persons
+func (ai attrIndexes) lookup(f Filter, total int) ([]uint64, bool) {
+	var (
+		best  []idSet
+		count = -1
+	)
+	consider := func(sets []idSet) {
+		n := 0
+		for _, ids := range sets {
+			n += len(ids)
+		}
+		if count < 0 || n < count {
+			best, count = sets, n
+		}
+	}
+
+	if f.MinAge > 0 || f.MaxAge > 0 {
+		var sets []idSet
+		for age, ids := range ai.byAge {
+			if (f.MinAge == 0 || age >= f.MinAge) && (f.MaxAge == 0 || age <= f.MaxAge) {
+				sets = append(sets, ids)
+			}
+		}
+		consider(sets)
+	}
+	if f.City != "" {
+		consider([]idSet{ai.byCity[strings.ToLower(f.City)]})
+	}
+	if len(f.Tags) > 0 {
+		var sets []idSet
+		for _, tag := range f.Tags {
+			sets = append(sets, ai.byTag[tag])
+		}
+		consider(sets)
+	}
+	if f.Near != nil {
+		if cells, ok := cellsWithin(*f.Near, f.MaxDistanceKm); ok {
+			var sets []idSet
+			for _, cell := range cells {
+				if ids, exist := ai.byCell[cell]; exist {
+					sets = append(sets, ids)
+				}
+			}
+			consider(sets)
+		}
+	}
+
+	if count < 0 || count*indexScanRatio > total {
+		return nil, false
+	}
+
+	// A person with several of the tags is in several sets
+	seen := make(idSet, count)
+	ids := make([]uint64, 0, count)
+	for _, set := range best {
+		for id := range set {
+			if _, dup := seen[id]; !dup {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, true
+}
@@ -0,0 +1,150 @@
+package tree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_FilterMatch(t *testing.T) {
+	taipei := entity.Location{Latitude: 25.033, Longitude: 121.565}
+	person := entity.Person{
+		Age:      30,
+		City:     "Taipei",
+		Location: &entity.Location{Latitude: 25.047, Longitude: 121.517},
+		Tags:     []string{"hiking", "jazz"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"No filter", Filter{}, true},
+		{"Age in range", Filter{MinAge: 25, MaxAge: 30}, true},
+		{"Too young", Filter{MinAge: 31}, false},
+		{"City ignores case", Filter{City: "taipei"}, true},
+		{"Other city", Filter{City: "Tainan"}, false},
+		{"One shared tag", Filter{Tags: []string{"chess", "jazz"}}, true},
+		{"No shared tag", Filter{Tags: []string{"chess"}}, false},
+		{"Near", Filter{Near: &taipei, MaxDistanceKm: 10}, true},
+		{"Too far", Filter{Near: &taipei, MaxDistanceKm: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(person))
+		})
+	}
+
+	assert.False(t, Filter{MaxAge: 40}.Match(entity.Person{}), "unknown age should not pass an age filter")
+	assert.False(t, Filter{Near: &taipei, MaxDistanceKm: 10}.Match(entity.Person{}), "unknown location should not pass a distance filter")
+}
+
+func Test_QueryWithIndexMatchesScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	cities := []string{"Taipei", "Tainan", "Hsinchu", "Keelung", "Yilan", "Hualien", "Taitung", "Penghu"}
+	tags := []string{"hiking", "jazz", "chess", "cooking", "films", "running", "travel", "cats"}
+
+	pt := NewPersonTree()
+	for id := uint64(1); id <= 400; id++ {
+		p := &entity.Person{
+			ID:          id,
+			Height:      150 + float64(rnd.Intn(40)),
+			Age:         18 + rnd.Intn(40),
+			City:        cities[rnd.Intn(len(cities))],
+			Tags:        []string{tags[rnd.Intn(len(tags))]},
+			WantedDates: cTypes.Uint64(1),
+		}
+		assert.Nil(t, pt.AddPerson(p))
+	}
+	// Moving people keeps the indexes in step
+	for id := uint64(1); id <= 400; id += 7 {
+		p, _ := pt.FindByID(id)
+		moved := *p
+		moved.City, moved.Age, moved.Tags = "Taipei", 20, []string{"cats"}
+		assert.Nil(t, pt.UpdatePerson(&moved))
+	}
+	for id := uint64(3); id <= 400; id += 11 {
+		assert.Nil(t, pt.RemovePerson(id))
+	}
+
+	filters := []Filter{
+		{City: "Penghu"},
+		{City: "taipei"},
+		{MinAge: 20, MaxAge: 21},
+		{Tags: []string{"cats"}},
+		{Tags: []string{"chess", "films"}, MinAge: 30},
+		{MinAge: 18},
+	}
+	for _, f := range filters {
+		q := Query{MinHeight: 160, MaxHeight: 180, Filter: f}
+
+		var want []entity.Person
+		for _, p := range pt.Snapshot() {
+			if p.Height >= q.MinHeight && p.Height <= q.MaxHeight && f.Match(p) {
+				want = append(want, p)
+			}
+		}
+
+		sort.Slice(want, func(i, j int) bool { return shortestFirst(want[i], want[j]) })
+		assert.Equal(t, want, pt.QueryOrdered(q, false, len(want)+1), "shortest %+v", f)
+
+		sort.Slice(want, func(i, j int) bool { return tallestFirst(want[i], want[j]) })
+		assert.Equal(t, want, pt.QueryOrdered(q, true, len(want)+1), "tallest %+v", f)
+
+		sort.Slice(want, func(i, j int) bool { return nearestFirst(171)(want[i], want[j]) })
+		assert.Equal(t, want, pt.QueryNearest(171, q, len(want)+1), "nearest %+v", f)
+
+		// Pages of the index agree with one query
+		var (
+			got   []entity.Person
+			after *Cursor
+		)
+		for {
+			q.After = after
+			page := pt.QueryNearest(171, q, 5)
+			got = append(got, page...)
+			if len(page) < 5 {
+				break
+			}
+			last := page[len(page)-1]
+			after = &Cursor{Height: last.Height, ID: last.ID}
+		}
+		assert.Equal(t, want, got, "pages %+v", f)
+
+		_, seen := pt.QuerySample(Query{MinHeight: 160, MaxHeight: 180, Filter: f}, 3)
+		assert.Equal(t, len(want), seen, "sample %+v", f)
+	}
+}
+
+func Test_attrIndexesLookup(t *testing.T) {
+	ai := newAttrIndexes()
+	for id := uint64(1); id <= 8; id++ {
+		city := "Taipei"
+		if id == 1 {
+			city = "Tainan"
+		}
+		ai.add(&entity.Person{ID: id, Age: 20 + int(id), City: city, Tags: []string{"jazz"}})
+	}
+
+	ids, ok := ai.lookup(Filter{City: "TAINAN", MinAge: 21}, 8)
+	assert.True(t, ok)
+	assert.Equal(t, []uint64{1}, ids, "the smallest set should be used")
+
+	_, ok = ai.lookup(Filter{City: "Taipei"}, 8)
+	assert.False(t, ok, "an index holding most persons is not worth it")
+
+	_, ok = ai.lookup(Filter{Near: &entity.Location{}, MaxDistanceKm: math.MaxFloat64}, 8)
+	assert.False(t, ok, "distance has no index")
+
+	ai.remove(1)
+	ids, ok = ai.lookup(Filter{City: "Tainan"}, 8)
+	assert.True(t, ok)
+	assert.Empty(t, ids)
+}
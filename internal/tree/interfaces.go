@@ -12,8 +12,19 @@ type (
 type (
 	PersonTreeIface interface {
 		AddPerson(p *entity.Person) error
+		// BulkAdd adds the persons under one lock, errs[i] is the error of persons[i]
+		BulkAdd(persons []*entity.Person) (errs []error)
 		RemovePerson(id uint64) error
+		UpdatePerson(p *entity.Person) error
 		QueryByHeight(minHeight float64, maxHeight float64) []entity.Person
+		QueryNearest(target float64, q Query, k int) []entity.Person
+		QueryOrdered(q Query, desc bool, k int) []entity.Person
+		QuerySample(q Query, k int) (sample []entity.Person, seen int)
 		FindByID(id uint64) (*entity.Person, bool)
+		Snapshot() []entity.Person
+		// Suspend hides the person from every query until Unsuspend, without removing it
+		Suspend(id uint64) error
+		Unsuspend(id uint64) error
+		Suspended() []entity.Person
 	}
 )
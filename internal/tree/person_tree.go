@@ -1,7 +1,11 @@
 package tree
 
 import (
+	"math"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/emirpasic/gods/trees/redblacktree"
 	"github.com/emirpasic/gods/utils"
@@ -13,18 +17,26 @@ import (
 var (
 	ErrorPersonExist    = errors.New("person exist")
 	ErrorPersonNotFound = errors.New("person not found")
+	// ErrorPersonSuspended is returned when suspending a person who already is
+	ErrorPersonSuspended = errors.New("person suspended")
 )
 
 type PersonTree struct {
 	tree  *redblacktree.Tree
 	idMap map[uint64]*entity.Person
-	mu    sync.RWMutex
+	// attrs indexes age, city and tags so filters on them need not scan idMap
+	attrs attrIndexes
+	// suspended persons are kept out of the height tree, the indexes and FindByID
+	suspended map[uint64]*entity.Person
+	mu        sync.RWMutex
 }
 
 func NewPersonTree() *PersonTree {
 	return &PersonTree{
-		tree:  redblacktree.NewWith(utils.Float64Comparator),
-		idMap: map[uint64]*entity.Person{},
+		tree:      redblacktree.NewWith(utils.Float64Comparator),
+		idMap:     map[uint64]*entity.Person{},
+		attrs:     newAttrIndexes(),
+		suspended: map[uint64]*entity.Person{},
 	}
 }
 
@@ -33,53 +45,192 @@ func (pt *PersonTree) AddPerson(p *entity.Person) error {
 	defer pt.mu.Unlock()
 
 	_, exist := pt.idMap[p.ID]
-	if exist {
+	_, suspended := pt.suspended[p.ID]
+	if exist || suspended {
 		return ErrorPersonExist
 	}
 
-	pt.idMap[p.ID] = p
-	if value, found := pt.tree.Get(p.Height); found {
-		people := value.([]uint64)
-		pt.tree.Put(p.Height, append(people, p.ID))
-		return nil
-	}
-	pt.tree.Put(p.Height, []uint64{p.ID})
+	pt.insert(p)
 
 	return nil
 }
 
+func (pt *PersonTree) BulkAdd(persons []*entity.Person) []error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	errs := make([]error, len(persons))
+	for i, p := range persons {
+		_, exist := pt.idMap[p.ID]
+		_, suspended := pt.suspended[p.ID]
+		if exist || suspended {
+			errs[i] = ErrorPersonExist
+			continue
+		}
+		pt.insert(p)
+	}
+
+	return errs
+}
+
+// RemovePerson removes the person for good, suspended or not
 func (pt *PersonTree) RemovePerson(id uint64) error {
+	_, _, err := pt.Take(id)
+	return err
+}
+
+// Take removes the person like RemovePerson and returns it with whether it was suspended,
+// so it can be put back with Put when a write that goes with the removal fails
+func (pt *PersonTree) Take(id uint64) (p *entity.Person, suspended bool, err error) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if p, suspended = pt.suspended[id]; suspended {
+		delete(pt.suspended, id)
+		return p, true, nil
+	}
+
+	p, err = pt.detach(id)
+	return p, false, err
+}
+
+// Put puts back a person returned by Take
+func (pt *PersonTree) Put(p *entity.Person, suspended bool) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if suspended {
+		pt.suspended[p.ID] = p
+		return
+	}
+	pt.insert(p)
+}
+
+// Suspend hides the person from queries and FindByID until Unsuspend
+func (pt *PersonTree) Suspend(id uint64) error {
 	pt.mu.Lock()
 	defer pt.mu.Unlock()
 
+	if _, suspended := pt.suspended[id]; suspended {
+		return ErrorPersonSuspended
+	}
+
+	person, err := pt.detach(id)
+	if err != nil {
+		return err
+	}
+	pt.suspended[id] = person
+
+	return nil
+}
+
+// Unsuspend puts a suspended person back, ErrorPersonNotFound when the person is not suspended
+func (pt *PersonTree) Unsuspend(id uint64) error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	person, suspended := pt.suspended[id]
+	if !suspended {
+		return ErrorPersonNotFound
+	}
+	delete(pt.suspended, id)
+	pt.insert(person)
+
+	return nil
+}
+
+// Suspended copies the suspended persons ordered by ID
+func (pt *PersonTree) Suspended() []entity.Person {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	result := make([]entity.Person, 0, len(pt.suspended))
+	for _, person := range pt.suspended {
+		result = append(result, copyPerson(person))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result
+}
+
+func (pt *PersonTree) insert(p *entity.Person) {
+	pt.idMap[p.ID] = p
+	pt.addToNode(p.ID, p.Height)
+	pt.attrs.add(p)
+}
+
+// detach takes the person out of idMap, the height tree and the indexes
+func (pt *PersonTree) detach(id uint64) (*entity.Person, error) {
 	person, exist := pt.idMap[id]
 	if !exist {
-		return ErrorPersonNotFound
+		return nil, ErrorPersonNotFound
 	}
 
-	value, found := pt.tree.Get(person.Height)
-	if !found {
+	if !pt.removeFromNode(id, person.Height) {
+		return nil, ErrorPersonNotFound
+	}
+	delete(pt.idMap, id)
+	pt.attrs.remove(id)
+
+	return person, nil
+}
+
+// UpdatePerson replaces the stored person and moves it to another node when the height changed,
+// p must be a copy rather than the stored person for the old height to be known
+func (pt *PersonTree) UpdatePerson(p *entity.Person) error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	person, exist := pt.idMap[p.ID]
+	if !exist {
 		return ErrorPersonNotFound
 	}
 
-	delete(pt.idMap, id)
+	if person.Height != p.Height {
+		if !pt.removeFromNode(p.ID, person.Height) {
+			return ErrorPersonNotFound
+		}
+		pt.addToNode(p.ID, p.Height)
+	}
+	pt.idMap[p.ID] = p
+	pt.attrs.remove(p.ID)
+	pt.attrs.add(p)
+
+	return nil
+}
+
+func (pt *PersonTree) addToNode(id uint64, height float64) {
+	if value, found := pt.tree.Get(height); found {
+		people := value.([]uint64)
+		pt.tree.Put(height, append(people, id))
+		return
+	}
+	pt.tree.Put(height, []uint64{id})
+}
+
+func (pt *PersonTree) removeFromNode(id uint64, height float64) bool {
+	value, found := pt.tree.Get(height)
+	if !found {
+		return false
+	}
 
 	ids := value.([]uint64)
-	for i, v := range ids {
-		if v != id {
-			continue
-		}
-		ids = append(ids[:i], ids[i+1:]...)
-		break
+	i := 0
+	for i < len(ids) && ids[i] != id {
+		i++
+	}
+	if i == len(ids) {
+		return false
 	}
+	ids = append(ids[:i], ids[i+1:]...)
 
 	if len(ids) == 0 {
-		pt.tree.Remove(person.Height)
-		return nil
+		pt.tree.Remove(height)
+		return true
 	}
 
-	pt.tree.Put(person.Height, ids)
-	return nil
+	pt.tree.Put(height, ids)
+	return true
 }
 
 func (pt *PersonTree) QueryByHeight(minHeight float64, maxHeight float64) []entity.Person {
@@ -122,6 +273,299 @@ func (pt *PersonTree) QueryByHeight(minHeight float64, maxHeight float64) []enti
 	return result
 }
 
+// Cursor is where a paged query stopped, the next page starts after the person
+// with ID at Height. Persons of the same height are returned in ID order.
+type Cursor struct {
+	Height float64
+	ID     uint64
+}
+
+// Query selects the persons with height in [MinHeight, MaxHeight] passing Filter and Accept
+type Query struct {
+	MinHeight float64
+	MaxHeight float64
+	Filter    Filter
+	// After resumes a paged query behind the cursor when not nil
+	After *Cursor
+	// Accept runs under the read lock and must not call back into the tree
+	Accept func(p entity.Person) bool
+}
+
+func (q Query) accepts(p entity.Person) bool {
+	return q.Filter.Match(p) && (q.Accept == nil || q.Accept(p))
+}
+
+// QueryNearest returns up to k persons of q expanding outward from target, so
+// the closest heights come first. On equal distance the shorter side is taken first.
+func (pt *PersonTree) QueryNearest(target float64, q Query, k int) []entity.Person {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	if k <= 0 || pt.tree.Root == nil || q.MinHeight > q.MaxHeight {
+		return nil
+	}
+	target = math.Max(q.MinHeight, math.Min(target, q.MaxHeight))
+
+	if ids, ok := pt.attrs.lookup(q.Filter, len(pt.idMap)); ok {
+		return pt.queryIndexed(ids, q, k, nearestFirst(target))
+	}
+
+	distance := func(height float64) float64 { return math.Abs(height - target) }
+	// done reports whether the node at height was returned in full before the cursor
+	done := func(height float64) bool {
+		if q.After == nil {
+			return false
+		}
+		d, cursor := distance(height), distance(q.After.Height)
+		return d < cursor || (d == cursor && height < q.After.Height)
+	}
+	start := 0.0
+	if q.After != nil {
+		start = distance(q.After.Height)
+	}
+
+	lower, hasLower := pt.seekLower(target, target-start, done)
+	hasLower = hasLower && lower.Key().(float64) >= q.MinHeight
+	upper, hasUpper := pt.seekUpper(target, target+start, done)
+	hasUpper = hasUpper && upper.Key().(float64) <= q.MaxHeight
+
+	var result []entity.Person
+	for len(result) < k && (hasLower || hasUpper) {
+		if hasLower && (!hasUpper || distance(lower.Key().(float64)) <= distance(upper.Key().(float64))) {
+			result = pt.appendAccepted(result, idsAfter(lower.Key().(float64), lower.Value().([]uint64), q.After), k, q.accepts)
+			hasLower = lower.Prev() && lower.Key().(float64) >= q.MinHeight
+			continue
+		}
+		result = pt.appendAccepted(result, idsAfter(upper.Key().(float64), upper.Value().([]uint64), q.After), k, q.accepts)
+		hasUpper = upper.Next() && upper.Key().(float64) <= q.MaxHeight
+	}
+
+	return result
+}
+
+// seekLower finds the tallest node not taller than target that is not done,
+// starting the search at bound
+func (pt *PersonTree) seekLower(target, bound float64, done func(height float64) bool) (redblacktree.Iterator, bool) {
+	node, found := pt.tree.Floor(math.Min(target, bound))
+	if !found {
+		node = pt.tree.Left()
+	}
+
+	iter, ok := pt.tree.IteratorAt(node), true
+	for ok && (iter.Key().(float64) > target || done(iter.Key().(float64))) {
+		ok = iter.Prev()
+	}
+	// bound is computed, step up over any node it fell short of
+	for next := iter; next.Next() && next.Key().(float64) <= target && !done(next.Key().(float64)); {
+		iter, ok = next, true
+	}
+	return iter, ok
+}
+
+// seekUpper finds the shortest node taller than target that is not done,
+// starting the search at bound
+func (pt *PersonTree) seekUpper(target, bound float64, done func(height float64) bool) (redblacktree.Iterator, bool) {
+	node, found := pt.tree.Ceiling(math.Max(target, bound))
+	if !found {
+		node = pt.tree.Right()
+	}
+
+	iter, ok := pt.tree.IteratorAt(node), true
+	for ok && (iter.Key().(float64) <= target || done(iter.Key().(float64))) {
+		ok = iter.Next()
+	}
+	// bound is computed, step down over any node it overshot
+	for prev := iter; prev.Prev() && prev.Key().(float64) > target && !done(prev.Key().(float64)); {
+		iter, ok = prev, true
+	}
+	return iter, ok
+}
+
+// QueryOrdered returns up to k persons of q, shortest first or tallest first when desc
+func (pt *PersonTree) QueryOrdered(q Query, desc bool, k int) []entity.Person {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	if k <= 0 {
+		return nil
+	}
+
+	if ids, ok := pt.attrs.lookup(q.Filter, len(pt.idMap)); ok {
+		less := shortestFirst
+		if desc {
+			less = tallestFirst
+		}
+		return pt.queryIndexed(ids, q, k, less)
+	}
+
+	// Resume at the node of the cursor, the persons already returned are skipped by idsAfter
+	minHeight, maxHeight := q.MinHeight, q.MaxHeight
+	if q.After != nil && desc {
+		maxHeight = math.Min(maxHeight, q.After.Height)
+	} else if q.After != nil {
+		minHeight = math.Max(minHeight, q.After.Height)
+	}
+
+	var result []entity.Person
+	pt.walk(minHeight, maxHeight, desc, func(height float64, ids []uint64) bool {
+		result = pt.appendAccepted(result, idsAfter(height, ids, q.After), k, q.accepts)
+		return len(result) < k
+	})
+	return result
+}
+
+// QuerySample returns up to k persons of q chosen uniformly at random and in
+// random order, and how many persons q selects. q.After is ignored.
+func (pt *PersonTree) QuerySample(q Query, k int) (sample []entity.Person, seen int) {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	if k <= 0 {
+		return nil, 0
+	}
+
+	// Reservoir sampling keeps only k persons however many q selects
+	pick := func(id uint64) {
+		person, exist := pt.idMap[id]
+		if !exist || !q.accepts(*person) {
+			return
+		}
+
+		seen++
+		if len(sample) < k {
+			sample = append(sample, *person)
+		} else if i := rand.Intn(seen); i < k {
+			sample[i] = *person
+		}
+	}
+
+	if ids, ok := pt.attrs.lookup(q.Filter, len(pt.idMap)); ok {
+		for _, id := range ids {
+			if height := pt.idMap[id].Height; height >= q.MinHeight && height <= q.MaxHeight {
+				pick(id)
+			}
+		}
+	} else {
+		pt.walk(q.MinHeight, q.MaxHeight, false, func(_ float64, ids []uint64) bool {
+			for _, id := range ids {
+				pick(id)
+			}
+			return true
+		})
+	}
+
+	rand.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+	return sample, seen
+}
+
+// queryIndexed returns up to k persons of q among ids in the order of less,
+// it stands in for walking the tree when a secondary index narrowed the persons down
+func (pt *PersonTree) queryIndexed(ids []uint64, q Query, k int, less func(a, b entity.Person) bool) []entity.Person {
+	var result []entity.Person
+	for _, id := range ids {
+		person, exist := pt.idMap[id]
+		if !exist || person.Height < q.MinHeight || person.Height > q.MaxHeight {
+			continue
+		}
+		if q.After != nil && !less(entity.Person{ID: q.After.ID, Height: q.After.Height}, *person) {
+			continue
+		}
+		if q.accepts(*person) {
+			result = append(result, *person)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return less(result[i], result[j]) })
+	if len(result) > k {
+		return result[:k]
+	}
+	return result
+}
+
+// nearestFirst orders the persons the way QueryNearest walks the tree
+func nearestFirst(target float64) func(a, b entity.Person) bool {
+	return func(a, b entity.Person) bool {
+		da, db := math.Abs(a.Height-target), math.Abs(b.Height-target)
+		if da != db {
+			return da < db
+		}
+		return shortestFirst(a, b)
+	}
+}
+
+func shortestFirst(a, b entity.Person) bool {
+	if a.Height != b.Height {
+		return a.Height < b.Height
+	}
+	return a.ID < b.ID
+}
+
+func tallestFirst(a, b entity.Person) bool {
+	if a.Height != b.Height {
+		return a.Height > b.Height
+	}
+	return a.ID < b.ID
+}
+
+// walk calls fn with the height and ids of every node with height in [minHeight, maxHeight]
+// in ascending order, or descending when desc, until fn returns false
+func (pt *PersonTree) walk(minHeight, maxHeight float64, desc bool, fn func(height float64, ids []uint64) bool) {
+	if pt.tree.Root == nil || minHeight > maxHeight {
+		return
+	}
+
+	if desc {
+		node, found := pt.tree.Floor(maxHeight)
+		if !found {
+			return
+		}
+		for iter := pt.tree.IteratorAt(node); iter.Key().(float64) >= minHeight; {
+			if !fn(iter.Key().(float64), iter.Value().([]uint64)) || !iter.Prev() {
+				return
+			}
+		}
+		return
+	}
+
+	node, found := pt.tree.Ceiling(minHeight)
+	if !found {
+		return
+	}
+	for iter := pt.tree.IteratorAt(node); iter.Key().(float64) <= maxHeight; {
+		if !fn(iter.Key().(float64), iter.Value().([]uint64)) || !iter.Next() {
+			return
+		}
+	}
+}
+
+// idsAfter returns the ids of the node at height in ascending order, leaving
+// out the ones up to after when it is the node of the cursor
+func idsAfter(height float64, ids []uint64, after *Cursor) []uint64 {
+	sorted := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if after == nil || height != after.Height || id > after.ID {
+			sorted = append(sorted, id)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// appendAccepted appends the accepted persons of ids to result until it holds k persons
+func (pt *PersonTree) appendAccepted(result []entity.Person, ids []uint64, k int, accept func(p entity.Person) bool) []entity.Person {
+	for _, id := range ids {
+		if len(result) >= k {
+			break
+		}
+		person, exist := pt.idMap[id]
+		if !exist || (accept != nil && !accept(*person)) {
+			continue
+		}
+		result = append(result, *person)
+	}
+	return result
+}
+
 func (pt *PersonTree) FindByID(id uint64) (*entity.Person, bool) {
 	pt.mu.RLock()
 	defer pt.mu.RUnlock()
@@ -129,3 +573,32 @@ func (pt *PersonTree) FindByID(id uint64) (*entity.Person, bool) {
 	person, exist := pt.idMap[id]
 	return person, exist
 }
+
+// Snapshot copies every person in height order under the read lock
+func (pt *PersonTree) Snapshot() []entity.Person {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	result := make([]entity.Person, 0, len(pt.idMap))
+	iter := pt.tree.Iterator()
+	for iter.Next() {
+		for _, id := range iter.Value().([]uint64) {
+			person, exist := pt.idMap[id]
+			if !exist {
+				continue
+			}
+
+			result = append(result, copyPerson(person))
+		}
+	}
+
+	return result
+}
+
+// copyPerson copies the person with its own wanted dates, which are decremented atomically
+func copyPerson(person *entity.Person) entity.Person {
+	p := *person
+	wantedDates := atomic.LoadUint64(person.WantedDates)
+	p.WantedDates = &wantedDates
+	return p
+}
@@ -2,6 +2,7 @@ package tree
 
 import (
 	"math"
+	"math/rand"
 	"reflect"
 	"slices"
 	"sort"
@@ -63,15 +64,18 @@ func (s *personTreeTestSuite) SetupTest() {
 	}
 
 	s.pt = &PersonTree{
-		tree:  redblacktree.NewWith(utils.Float64Comparator),
-		idMap: idMap,
-		mu:    sync.RWMutex{},
+		tree:      redblacktree.NewWith(utils.Float64Comparator),
+		idMap:     idMap,
+		attrs:     newAttrIndexes(),
+		suspended: map[uint64]*entity.Person{},
+		mu:        sync.RWMutex{},
 	}
 	insertPersonToTree(s.pt, idMap)
 }
 
 func insertPersonToTree(pt *PersonTree, idMap map[uint64]*entity.Person) {
 	for _, p := range idMap {
+		pt.attrs.add(p)
 		if value, found := pt.tree.Get(p.Height); found {
 			people := value.([]uint64)
 			pt.tree.Put(p.Height, append(people, p.ID))
@@ -150,6 +154,183 @@ func (s *personTreeTestSuite) Test_QueryByHeight() {
 	}
 }
 
+func heightsOf(people []entity.Person) []float64 {
+	var heights []float64
+	for _, p := range people {
+		heights = append(heights, p.Height)
+	}
+	return heights
+}
+
+func (s *personTreeTestSuite) Test_QueryNearest() {
+	tests := []struct {
+		name      string
+		target    float64
+		minHeight float64
+		maxHeight float64
+		k         int
+		accept    func(entity.Person) bool
+		want      []float64
+	}{
+		{
+			name:      "Exact height first",
+			target:    160,
+			maxHeight: math.MaxFloat64,
+			k:         3,
+			want:      []float64{160, 155, 155},
+		},
+		{
+			name:      "Shorter side first on equal distance",
+			target:    165,
+			maxHeight: math.MaxFloat64,
+			k:         2,
+			want:      []float64{160, 170},
+		},
+		{
+			name:      "Target outside the range",
+			target:    200,
+			minHeight: 150,
+			maxHeight: 160,
+			k:         2,
+			want:      []float64{160, 155},
+		},
+		{
+			name:      "Skip rejected",
+			target:    150,
+			maxHeight: math.MaxFloat64,
+			k:         2,
+			accept:    func(p entity.Person) bool { return p.Height != 155 },
+			want:      []float64{150, 160},
+		},
+		{
+			name:      "Fewer than k",
+			target:    170,
+			minHeight: 165,
+			maxHeight: math.MaxFloat64,
+			k:         3,
+			want:      []float64{170},
+		},
+		{
+			name:      "Empty range",
+			target:    165,
+			minHeight: 161,
+			maxHeight: 169,
+			k:         3,
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			got := s.pt.QueryNearest(tt.target, Query{MinHeight: tt.minHeight, MaxHeight: tt.maxHeight, Accept: tt.accept}, tt.k)
+			assert.Equal(t, tt.want, heightsOf(got))
+		})
+	}
+}
+
+func (s *personTreeTestSuite) Test_QueryOrdered() {
+	assert.Equal(s.T(), []float64{150, 155, 155}, heightsOf(s.pt.QueryOrdered(Query{MaxHeight: math.MaxFloat64}, false, 3)))
+	assert.Equal(s.T(), []float64{170, 160}, heightsOf(s.pt.QueryOrdered(Query{MaxHeight: math.MaxFloat64}, true, 2)))
+	assert.Equal(s.T(), []float64{160, 155, 155}, heightsOf(s.pt.QueryOrdered(Query{MinHeight: 151, MaxHeight: 165}, true, 5)))
+	assert.Nil(s.T(), s.pt.QueryOrdered(Query{MinHeight: 171, MaxHeight: math.MaxFloat64}, false, 5))
+}
+
+func (s *personTreeTestSuite) Test_QueryAfterCursor() {
+	// pages reads the whole range two at a time, resuming after the last person of each page
+	pages := func(query func(after *Cursor) []entity.Person) []uint64 {
+		var (
+			ids   []uint64
+			after *Cursor
+		)
+		for {
+			page := query(after)
+			for _, p := range page {
+				ids = append(ids, p.ID)
+			}
+			if len(page) < 2 {
+				return ids
+			}
+			last := page[len(page)-1]
+			after = &Cursor{Height: last.Height, ID: last.ID}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query func(after *Cursor) []entity.Person
+		want  []uint64
+	}{
+		{
+			name: "Shortest",
+			query: func(after *Cursor) []entity.Person {
+				return s.pt.QueryOrdered(Query{MaxHeight: math.MaxFloat64, After: after}, false, 2)
+			},
+			want: []uint64{1, 2, 3, 4, 5},
+		},
+		{
+			name: "Tallest",
+			query: func(after *Cursor) []entity.Person {
+				return s.pt.QueryOrdered(Query{MaxHeight: math.MaxFloat64, After: after}, true, 2)
+			},
+			want: []uint64{5, 4, 2, 3, 1},
+		},
+		{
+			name: "Nearest below",
+			query: func(after *Cursor) []entity.Person {
+				return s.pt.QueryNearest(158, Query{MaxHeight: math.MaxFloat64, After: after}, 2)
+			},
+			want: []uint64{4, 2, 3, 1, 5},
+		},
+		{
+			name: "Nearest tie",
+			query: func(after *Cursor) []entity.Person {
+				return s.pt.QueryNearest(157.5, Query{MaxHeight: math.MaxFloat64, After: after}, 2)
+			},
+			want: []uint64{2, 3, 4, 1, 5},
+		},
+		{
+			name: "Nearest above",
+			query: func(after *Cursor) []entity.Person {
+				return s.pt.QueryNearest(162, Query{MaxHeight: math.MaxFloat64, After: after}, 2)
+			},
+			want: []uint64{4, 2, 3, 5, 1},
+		},
+		{
+			name: "Nearest exact",
+			query: func(after *Cursor) []entity.Person {
+				return s.pt.QueryNearest(155, Query{MinHeight: 152, MaxHeight: 165, After: after}, 2)
+			},
+			want: []uint64{2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pages(tt.query))
+		})
+	}
+}
+
+func (s *personTreeTestSuite) Test_QuerySample() {
+	notFirst := func(p entity.Person) bool { return p.ID != 1 }
+
+	counts := map[uint64]int{}
+	for i := 0; i < 1000; i++ {
+		sample, seen := s.pt.QuerySample(Query{MaxHeight: math.MaxFloat64, Accept: notFirst}, 2)
+		assert.Equal(s.T(), 4, seen)
+		assert.Len(s.T(), sample, 2)
+		for _, p := range sample {
+			counts[p.ID]++
+		}
+	}
+
+	// Every accepted person is picked about half the time
+	assert.Zero(s.T(), counts[1])
+	for id := uint64(2); id <= 5; id++ {
+		assert.InDelta(s.T(), 500, counts[id], 100, "person %d", id)
+	}
+}
+
 func (s *personTreeTestSuite) Test_AddPerson() {
 	person := entity.Person{
 		ID:          99999,
@@ -187,6 +368,24 @@ func (s *personTreeTestSuite) Test_AddPerson() {
 	}
 }
 
+func (s *personTreeTestSuite) Test_BulkAdd() {
+	assert.Nil(s.T(), s.pt.Suspend(5))
+	persons := []*entity.Person{
+		{ID: 6, Name: "6", Height: 155, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "2", Height: 155, WantedDates: cTypes.Uint64(1)},
+		{ID: 7, Name: "7", Height: 180, WantedDates: cTypes.Uint64(1)},
+		{ID: 5, Name: "5", Height: 170, WantedDates: cTypes.Uint64(1)},
+		{ID: 7, Name: "7 again", Height: 190, WantedDates: cTypes.Uint64(1)},
+	}
+
+	errs := s.pt.BulkAdd(persons)
+	assert.Equal(s.T(), []error{nil, ErrorPersonExist, nil, ErrorPersonExist, ErrorPersonExist}, errs)
+	s.checkPersonAdded(*persons[0])
+	s.checkPersonAdded(*persons[2])
+	p, _ := s.pt.FindByID(2)
+	assert.Equal(s.T(), "2", p.Name, "existing person should be kept")
+}
+
 func (s *personTreeTestSuite) checkPersonAdded(p entity.Person) {
 	s.pt.mu.RLock()
 	defer s.pt.mu.RUnlock()
@@ -265,3 +464,138 @@ func (s *personTreeTestSuite) checkPersonRemoved(p entity.Person, wantRemoveNode
 	gotIDs := value.([]uint64)
 	assert.False(s.T(), slices.Contains(gotIDs, p.ID), "person id should not be found in node")
 }
+
+func (s *personTreeTestSuite) Test_UpdatePerson() {
+	tests := []struct {
+		name           string
+		p              entity.Person
+		oldHeight      float64
+		wantErr        error
+		wantRemoveNode bool
+	}{
+		{
+			"Update wanted dates",
+			entity.Person{ID: 4, Name: "4", Height: 160, WantedDates: cTypes.Uint64(5)},
+			160,
+			nil,
+			false,
+		},
+		{
+			"Move to existing node",
+			entity.Person{ID: 2, Name: "2", Height: 150, WantedDates: cTypes.Uint64(2)},
+			155,
+			nil,
+			false,
+		},
+		{
+			"Move and remove old node",
+			entity.Person{ID: 5, Name: "5", Height: 180, WantedDates: cTypes.Uint64(1)},
+			170,
+			nil,
+			true,
+		},
+		{
+			"Person not found",
+			entity.Person{ID: 99, Name: "99", Height: 180, WantedDates: cTypes.Uint64(1)},
+			0,
+			ErrorPersonNotFound,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			err := s.pt.UpdatePerson(&tt.p)
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+
+			s.checkPersonAdded(tt.p)
+			if tt.oldHeight == tt.p.Height {
+				return
+			}
+
+			s.pt.mu.RLock()
+			defer s.pt.mu.RUnlock()
+			value, exist := s.pt.tree.Get(tt.oldHeight)
+			if tt.wantRemoveNode {
+				assert.False(t, exist, "old node should not be found in tree")
+				return
+			}
+			assert.True(t, exist, "old node should be found in tree")
+			assert.False(t, slices.Contains(value.([]uint64), tt.p.ID), "person id should not be found in old node")
+		})
+	}
+}
+
+func (s *personTreeTestSuite) Test_Snapshot() {
+	got := s.pt.Snapshot()
+
+	var gotIDs []uint64
+	for _, person := range got {
+		gotIDs = append(gotIDs, person.ID)
+	}
+	assert.Equal(s.T(), uint64(1), gotIDs[0])
+	assert.ElementsMatch(s.T(), []uint64{2, 3}, gotIDs[1:3])
+	assert.Equal(s.T(), []uint64{4, 5}, gotIDs[3:])
+
+	// Changes after the snapshot must not leak into it
+	person, _ := s.pt.FindByID(1)
+	*person.WantedDates = 0
+	assert.Equal(s.T(), uint64(1), *got[0].WantedDates)
+}
+
+func (s *personTreeTestSuite) Test_Suspend() {
+	assert.Nil(s.T(), s.pt.Suspend(2))
+	assert.ErrorIs(s.T(), s.pt.Suspend(2), ErrorPersonSuspended)
+	assert.ErrorIs(s.T(), s.pt.Suspend(6), ErrorPersonNotFound)
+
+	_, exist := s.pt.FindByID(2)
+	assert.False(s.T(), exist, "suspended person should be hidden")
+	assert.Equal(s.T(), []float64{150, 155, 160, 170}, heightsOf(s.pt.QueryNearest(150, Query{MaxHeight: math.MaxFloat64}, 5)))
+	assert.Len(s.T(), s.pt.Snapshot(), 4)
+	if suspended := s.pt.Suspended(); assert.Len(s.T(), suspended, 1) {
+		assert.Equal(s.T(), uint64(2), suspended[0].ID)
+	}
+	assert.ErrorIs(s.T(), s.pt.AddPerson(&entity.Person{ID: 2, Height: 180}), ErrorPersonExist)
+
+	assert.Nil(s.T(), s.pt.Unsuspend(2))
+	assert.ErrorIs(s.T(), s.pt.Unsuspend(2), ErrorPersonNotFound)
+	assert.Equal(s.T(), []float64{150, 155, 155, 160, 170}, heightsOf(s.pt.QueryNearest(150, Query{MaxHeight: math.MaxFloat64}, 5)))
+	assert.Empty(s.T(), s.pt.Suspended())
+
+	// A suspended person can still be removed for good
+	assert.Nil(s.T(), s.pt.Suspend(5))
+	assert.Nil(s.T(), s.pt.RemovePerson(5))
+	assert.ErrorIs(s.T(), s.pt.Unsuspend(5), ErrorPersonNotFound)
+}
+
+func Test_QueryNearestPagesMatchOneQuery(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 100; round++ {
+		pt := NewPersonTree()
+		for id := uint64(1); id <= 30; id++ {
+			// Few distinct heights so nodes hold several persons
+			height := 150 + float64(rnd.Intn(20))*0.1
+			assert.Nil(t, pt.AddPerson(&entity.Person{ID: id, Height: height, WantedDates: cTypes.Uint64(1)}))
+		}
+		target := 150 + rnd.Float64()*2
+
+		want := pt.QueryNearest(target, Query{MaxHeight: math.MaxFloat64}, 30)
+		var (
+			got   []entity.Person
+			after *Cursor
+		)
+		for {
+			page := pt.QueryNearest(target, Query{MaxHeight: math.MaxFloat64, After: after}, 4)
+			got = append(got, page...)
+			if len(page) < 4 {
+				break
+			}
+			last := page[len(page)-1]
+			after = &Cursor{Height: last.Height, ID: last.ID}
+		}
+		assert.Equal(t, want, got, "round %d target %v", round, target)
+	}
+}
@@ -0,0 +1,361 @@
+// Package wal implements an fsync'd append-only log of person mutations.
+//
+// Each record is framed as a 4 byte big endian payload length, a 4 byte
+// CRC-32 of the payload and the JSON encoded payload. A record that was only
+// partly written before a crash fails the length or checksum check and is
+// truncated when the log is opened. A payload is at most maxRecordSize bytes,
+// a complete record claiming more is corruption and fails the open.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ars0915/matching-system/entity"
+)
+
+type Op string
+
+const (
+	OpAddPerson    Op = "addPerson"
+	OpRemovePerson Op = "removePerson"
+	// OpUpdatePerson applies Patch to person ID
+	OpUpdatePerson Op = "updatePerson"
+	// OpMatch pairs two people without their likes, written by matching rounds
+	// and by Match before it required both sides to like each other
+	OpMatch       Op = "match"
+	OpMutualMatch Op = "mutualMatch"
+	// OpLike and OpPass record the reaction of person ID1 on candidate ID2
+	OpLike  Op = "like"
+	OpPass  Op = "pass"
+	OpBlock Op = "block"
+	// OpUnblock lifts the block of person ID1 on ID2
+	OpUnblock Op = "unblock"
+	// OpReport files Report, OpResolveReport closes Report.ID with Report.Status
+	OpReport        Op = "report"
+	OpResolveReport Op = "resolveReport"
+	OpSuspend       Op = "suspend"
+	OpUnsuspend     Op = "unsuspend"
+	// OpBatch applies the records of Batch in order, see AppendBatch
+	OpBatch Op = "batch"
+)
+
+const (
+	headerSize    = 8
+	maxRecordSize = 1 << 20
+	// batchOverhead bounds the payload of an OpBatch record besides its Batch
+	batchOverhead = 256
+)
+
+// ErrRecordTooLarge is returned for a record whose payload is over maxRecordSize, nothing is written
+var ErrRecordTooLarge = errors.New("wal record too large")
+
+type Record struct {
+	Seq    uint64
+	Op     Op
+	Time   time.Time
+	Person *entity.Person      `json:",omitempty"`
+	Report *entity.Report      `json:",omitempty"`
+	Patch  *entity.PersonPatch `json:",omitempty"`
+	ID     uint64              `json:",omitempty"`
+	ID1    uint64              `json:",omitempty"`
+	ID2    uint64              `json:",omitempty"`
+	Batch  []Record            `json:",omitempty"`
+}
+
+type Log struct {
+	path string
+	f    *os.File
+	seq  uint64
+	mu   sync.Mutex
+}
+
+// Open opens the log at path, truncates a torn final record and returns the
+// records after lastSeq, the sequence already covered by the latest snapshot
+func Open(path string, lastSeq uint64) (*Log, []Record, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "open wal")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, errors.Wrap(err, "stat wal")
+	}
+
+	records, validSize, err := readRecords(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.Size() > validSize {
+		logrus.WithFields(logrus.Fields{
+			"path":      path,
+			"validSize": validSize,
+			"fileSize":  info.Size(),
+		}).Warning("Truncate torn wal record")
+
+		if err := f.Truncate(validSize); err != nil {
+			f.Close()
+			return nil, nil, errors.Wrap(err, "truncate wal")
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return nil, nil, errors.Wrap(err, "sync wal")
+		}
+	}
+	if _, err := f.Seek(validSize, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, errors.Wrap(err, "seek wal")
+	}
+
+	l := &Log{
+		path: path,
+		f:    f,
+		seq:  lastSeq,
+	}
+
+	var pending []Record
+	for _, r := range records {
+		if r.Seq > l.seq {
+			l.seq = r.Seq
+		}
+		if r.Seq > lastSeq {
+			pending = append(pending, r)
+		}
+	}
+
+	return l, pending, nil
+}
+
+// Append assigns the next sequence to r and returns once it is on disk
+func (l *Log) Append(r Record) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r.Seq = l.seq + 1
+	if err := writeRecord(l.f, r); err != nil {
+		return 0, err
+	}
+	if err := l.f.Sync(); err != nil {
+		return 0, errors.Wrap(err, "sync wal")
+	}
+
+	l.seq = r.Seq
+	return r.Seq, nil
+}
+
+// AppendBatch appends records at time at as OpBatch records, as many in each as fit in
+// maxRecordSize, and returns once all of them are on disk. A crash in between may keep
+// only the first OpBatch records, so the records must not depend on each other.
+func (l *Log) AppendBatch(records []Record, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	chunks, err := splitBatch(records)
+	if err != nil {
+		return err
+	}
+
+	var (
+		buf bytes.Buffer
+		seq = l.seq
+	)
+	for _, batch := range chunks {
+		seq++
+		if err := writeRecord(&buf, Record{Seq: seq, Op: OpBatch, Time: at, Batch: batch}); err != nil {
+			return err
+		}
+	}
+	if _, err := l.f.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, "write wal record")
+	}
+	if err := l.f.Sync(); err != nil {
+		return errors.Wrap(err, "sync wal")
+	}
+
+	l.seq = seq
+	return nil
+}
+
+// splitBatch groups records so that each group encoded as the Batch of a record stays within maxRecordSize
+func splitBatch(records []Record) ([][]Record, error) {
+	var (
+		chunks [][]Record
+		start  int
+		size   = batchOverhead
+	)
+	for i, r := range records {
+		payload, err := json.Marshal(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "encode wal record")
+		}
+		// One more byte for the comma between the records
+		n := len(payload) + 1
+		if batchOverhead+n > maxRecordSize {
+			return nil, errors.Wrapf(ErrRecordTooLarge, "batch item of %d bytes", len(payload))
+		}
+		if size+n > maxRecordSize {
+			chunks = append(chunks, records[start:i])
+			start, size = i, batchOverhead
+		}
+		size += n
+	}
+	if start < len(records) {
+		chunks = append(chunks, records[start:])
+	}
+	return chunks, nil
+}
+
+// Seq returns the sequence of the last appended record
+func (l *Log) Seq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.seq
+}
+
+// Compact drops the records up to seq, which a snapshot already covers
+func (l *Log) Compact(seq uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info, err := l.f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat wal")
+	}
+	if _, err := l.f.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "seek wal")
+	}
+	records, _, err := readRecords(l.f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "create temp wal")
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, r := range records {
+		if r.Seq <= seq {
+			continue
+		}
+		if err := writeRecord(w, r); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "flush wal")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "sync wal")
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "rename wal")
+	}
+
+	// Keep appending to the compacted file
+	if _, err := tmp.Seek(0, io.SeekEnd); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "seek wal")
+	}
+	l.f.Close()
+	l.f = tmp
+
+	return nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.f.Close()
+}
+
+func writeRecord(w io.Writer, r Record) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "encode wal record")
+	}
+	if len(payload) > maxRecordSize {
+		return errors.Wrapf(ErrRecordTooLarge, "%s record of %d bytes", r.Op, len(payload))
+	}
+
+	header := make([]byte, headerSize)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return errors.Wrap(err, "write wal record")
+	}
+	return nil
+}
+
+// readRecords reads from the start of the file of fileSize bytes until the end or
+// the first incomplete or corrupt record, and returns the size of the valid prefix
+func readRecords(f *os.File, fileSize int64) ([]Record, int64, error) {
+	var (
+		records []Record
+		size    int64
+		r       = bufio.NewReader(f)
+		header  = make([]byte, headerSize)
+	)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, size, nil
+			}
+			return nil, 0, errors.Wrap(err, "read wal header")
+		}
+
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > maxRecordSize {
+			// The header is written with its payload, so a record past the end of the
+			// file is torn while a whole one is corrupt and must not be truncated away
+			if size+headerSize+int64(length) <= fileSize {
+				return nil, 0, errors.Errorf("wal record at offset %d claims %d bytes, over the limit of %d", size, length, maxRecordSize)
+			}
+			return records, size, nil
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return records, size, nil
+			}
+			return nil, 0, errors.Wrap(err, "read wal payload")
+		}
+
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			return records, size, nil
+		}
+
+		var record Record
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return records, size, nil
+		}
+
+		records = append(records, record)
+		size += int64(headerSize) + int64(length)
+	}
+}
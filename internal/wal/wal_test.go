@@ -0,0 +1,199 @@
+package wal
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+type walTestSuite struct {
+	suite.Suite
+
+	path string
+	log  *Log
+}
+
+func Test_walTestSuite(t *testing.T) {
+	suite.Run(t, &walTestSuite{})
+}
+
+func (s *walTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "matching.wal")
+
+	var records []Record
+	var err error
+	s.log, records, err = Open(s.path, 0)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), records)
+
+	s.append(
+		Record{Op: OpAddPerson, Person: &entity.Person{
+			ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(2),
+		}},
+		Record{Op: OpAddPerson, Person: &entity.Person{
+			ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1),
+		}},
+		Record{Op: OpMatch, ID1: 1, ID2: 2},
+	)
+}
+
+func (s *walTestSuite) TearDownTest() {
+	s.log.Close()
+}
+
+func (s *walTestSuite) append(records ...Record) {
+	for _, r := range records {
+		_, err := s.log.Append(r)
+		assert.Nil(s.T(), err)
+	}
+}
+
+func (s *walTestSuite) reopen(lastSeq uint64) []Record {
+	s.log.Close()
+
+	var records []Record
+	var err error
+	s.log, records, err = Open(s.path, lastSeq)
+	assert.Nil(s.T(), err)
+	return records
+}
+
+func (s *walTestSuite) Test_Replay() {
+	records := s.reopen(0)
+	assert.Equal(s.T(), []uint64{1, 2, 3}, seqs(records))
+	assert.Equal(s.T(), OpMatch, records[2].Op)
+	assert.Equal(s.T(), uint64(2), *records[0].Person.WantedDates)
+
+	records = s.reopen(2)
+	assert.Equal(s.T(), []uint64{3}, seqs(records), "records covered by the snapshot should be skipped")
+}
+
+func (s *walTestSuite) Test_TornFinalRecord() {
+	info, err := os.Stat(s.path)
+	assert.Nil(s.T(), err)
+	validSize := info.Size()
+
+	// Simulate a crash in the middle of writing the next record
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	assert.Nil(s.T(), err)
+	_, err = f.Write([]byte{0, 0, 0, 40, 1, 2, 3, 4, '{', '"'})
+	assert.Nil(s.T(), err)
+	f.Close()
+
+	records := s.reopen(0)
+	assert.Equal(s.T(), []uint64{1, 2, 3}, seqs(records))
+
+	info, err = os.Stat(s.path)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), validSize, info.Size(), "torn record should be truncated")
+
+	s.append(Record{Op: OpRemovePerson, ID: 1})
+	records = s.reopen(0)
+	assert.Equal(s.T(), []uint64{1, 2, 3, 4}, seqs(records))
+}
+
+func (s *walTestSuite) Test_CorruptFinalRecord() {
+	info, err := os.Stat(s.path)
+	assert.Nil(s.T(), err)
+
+	// Flip the last byte of the final record so its checksum fails
+	data, err := os.ReadFile(s.path)
+	assert.Nil(s.T(), err)
+	data[info.Size()-1] ^= 0xff
+	assert.Nil(s.T(), os.WriteFile(s.path, data, 0o644))
+
+	records := s.reopen(0)
+	assert.Equal(s.T(), []uint64{1, 2}, seqs(records))
+}
+
+func (s *walTestSuite) Test_Compact() {
+	assert.Nil(s.T(), s.log.Compact(2))
+	s.append(Record{Op: OpRemovePerson, ID: 1})
+	assert.Equal(s.T(), uint64(4), s.log.Seq())
+
+	records := s.reopen(2)
+	assert.Equal(s.T(), []uint64{3, 4}, seqs(records))
+
+	// Sequence continues from the snapshot when the log was compacted to empty
+	assert.Nil(s.T(), s.log.Compact(4))
+	records = s.reopen(4)
+	assert.Empty(s.T(), records)
+	seq, err := s.log.Append(Record{Op: OpRemovePerson, ID: 2})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(5), seq)
+}
+
+func seqs(records []Record) []uint64 {
+	var result []uint64
+	for _, r := range records {
+		result = append(result, r.Seq)
+	}
+	return result
+}
+
+func (s *walTestSuite) Test_AppendBatch() {
+	name := strings.Repeat("a", 1000)
+	batch := make([]Record, 3000)
+	for i := range batch {
+		batch[i] = Record{Op: OpAddPerson, Person: &entity.Person{ID: uint64(i + 4), Name: name}}
+	}
+	at := time.Now()
+	assert.Nil(s.T(), s.log.AppendBatch(batch, at))
+
+	records := s.reopen(0)
+	assert.Greater(s.T(), len(records), 4, "the batch should be split under the record size")
+	var got []Record
+	for _, r := range records[3:] {
+		assert.Equal(s.T(), OpBatch, r.Op)
+		assert.True(s.T(), at.Equal(r.Time))
+		got = append(got, r.Batch...)
+	}
+	assert.Equal(s.T(), len(batch), len(got))
+	assert.Equal(s.T(), uint64(len(batch)+3), got[len(got)-1].Person.ID)
+	assert.Equal(s.T(), records[len(records)-1].Seq, s.log.Seq())
+}
+
+func (s *walTestSuite) Test_RecordTooLarge() {
+	huge := Record{Op: OpAddPerson, Person: &entity.Person{ID: 4, Name: strings.Repeat("a", maxRecordSize)}}
+	_, err := s.log.Append(huge)
+	assert.ErrorIs(s.T(), err, ErrRecordTooLarge)
+	assert.ErrorIs(s.T(), s.log.AppendBatch([]Record{huge}, time.Now()), ErrRecordTooLarge)
+	assert.Equal(s.T(), uint64(3), s.log.Seq())
+
+	records := s.reopen(0)
+	assert.Equal(s.T(), []uint64{1, 2, 3}, seqs(records), "nothing should be written")
+}
+
+func (s *walTestSuite) Test_CompleteRecordOverLimit() {
+	info, err := os.Stat(s.path)
+	assert.Nil(s.T(), err)
+
+	// A whole record over the limit is not a torn tail and must not be truncated
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	assert.Nil(s.T(), err)
+	header := make([]byte, headerSize)
+	binary.BigEndian.PutUint32(header, maxRecordSize+1)
+	_, err = f.Write(append(header, make([]byte, maxRecordSize+1)...))
+	assert.Nil(s.T(), err)
+	f.Close()
+
+	s.log.Close()
+	_, _, err = Open(s.path, 0)
+	assert.NotNil(s.T(), err)
+	s.log, _, err = Open(filepath.Join(s.T().TempDir(), "other.wal"), 0)
+	assert.Nil(s.T(), err)
+
+	after, err := os.Stat(s.path)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), info.Size()+headerSize+maxRecordSize+1, after.Size())
+}
@@ -10,7 +10,14 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/ars0915/matching-system/config"
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
+	"github.com/ars0915/matching-system/internal/snapshot"
+	"github.com/ars0915/matching-system/internal/sqlite"
 	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/internal/wal"
 	"github.com/ars0915/matching-system/router"
 	"github.com/ars0915/matching-system/usecase"
 	"github.com/ars0915/matching-system/util/log"
@@ -47,6 +54,18 @@ func init() {
 			Usage:       "Configuration file path",
 		},
 	}
+	app.Commands = []cli.Command{
+		{
+			Name:  "migrate",
+			Usage: "Show the schema version and apply pending migrations",
+			Action: func(c *cli.Context) error {
+				if err := initConf(); err != nil {
+					return err
+				}
+				return migrateSQLite()
+			},
+		},
+	}
 	app.Action = func(c *cli.Context) error {
 		ctx, cancel := context.WithCancel(context.Background())
 		go func() {
@@ -61,22 +80,94 @@ func init() {
 			}
 		}()
 
-		// set default parameters.
-		if err := config.InitConf(configFile); err != nil {
-			logrus.Errorf("Load yaml config file error: '%v'", err)
+		if err := initConf(); err != nil {
 			return err
 		}
 
+		if rollback > 0 {
+			return rollbackSQLite(rollback)
+		}
+
 		logrus.WithFields(logrus.Fields{
 			"logLevel": logrus.GetLevel(),
 		}).Info("matching-system starting")
 
-		log.SetLogLevel(config.Conf.Log.Level)
+		policy, err := usecase.NewMatchPolicy(config.Conf.Match.Policy, config.Conf.Match.HeightTolerance)
+		if err != nil {
+			return err
+		}
+		scoring, err := usecase.NewScoring(config.Conf.Score.Weights)
+		if err != nil {
+			return err
+		}
+
+		var (
+			trees         = map[constant.Gender]tree.Tree{}
+			matchStore    matchstore.Store
+			reactionStore reactionstore.Store
+			reportStore   reportstore.Store
+			personOpts    = []usecase.PersonHandlerOption{
+				usecase.WithMatchPolicy(policy),
+				usecase.WithScoring(scoring),
+				usecase.WithRematch(config.Conf.Match.AllowRematch),
+			}
+			compactWAL bool
+		)
+		if config.Conf.Match.LikeTTL > 0 {
+			personOpts = append(personOpts, usecase.WithLikeTTL(config.Conf.Match.LikeTTL))
+		}
+		switch config.Conf.Core.Storage {
+		case constant.StorageSQLite:
+			db, err := sqlite.Open(config.Conf.SQLite)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			if _, err := sqlite.Migrate(db); err != nil {
+				return err
+			}
+
+			for _, g := range constant.Genders {
+				if trees[g], err = sqlite.NewPersonTree(db, g); err != nil {
+					return err
+				}
+			}
+			matchStore = sqlite.NewMatchStore(db)
+			reactionStore = sqlite.NewReactionStore(db)
+			reportStore = sqlite.NewReportStore(db)
+
+			lastID, err := sqlite.LastPersonID(db)
+			if err != nil {
+				return err
+			}
+			personOpts = append(personOpts, usecase.WithLastPersonID(lastID))
+		default:
+			for _, g := range constant.Genders {
+				trees[g] = tree.NewPersonTree()
+			}
+			matchStore = matchstore.NewMatchStore()
+			reactionStore = reactionstore.NewReactionStore()
+			reportStore = reportstore.NewReportStore()
+
+			opts, walLog, replayed, err := recoverMemory(trees, matchStore, reactionStore, reportStore)
+			if err != nil {
+				return err
+			}
+			if walLog != nil {
+				defer walLog.Close()
+			}
+			personOpts = append(personOpts, opts...)
+
+			compactWAL = replayed > 0 && config.Conf.Snapshot.Path != ""
+		}
 
-		boysTree := tree.NewPersonTree()
-		girlsTree := tree.NewPersonTree()
+		uHandler := usecase.InitHandler(trees, matchStore, reactionStore, reportStore, config.Conf.Snapshot.Path, personOpts...)
 
-		uHandler := usecase.InitHandler(boysTree, girlsTree)
+		// Compact the replayed log in the background by taking a new snapshot
+		if compactWAL {
+			go compactOnBoot(ctx, uHandler)
+		}
 
 		service := router.NewHandler(config.Conf, uHandler)
 
@@ -88,6 +179,123 @@ func init() {
 	}
 }
 
+func initConf() error {
+	// set default parameters.
+	if err := config.InitConf(configFile); err != nil {
+		logrus.Errorf("Load yaml config file error: '%v'", err)
+		return err
+	}
+
+	log.SetLogLevel(config.Conf.Log.Level)
+	return nil
+}
+
+func migrateSQLite() error {
+	db, err := sqlite.Open(config.Conf.SQLite)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := sqlite.Migrate(db)
+	if err != nil {
+		return err
+	}
+
+	version, err := sqlite.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"applied": applied,
+		"version": version,
+		"latest":  sqlite.LatestVersion(),
+	}).Info("Migrate finished")
+	return nil
+}
+
+func rollbackSQLite(steps int) error {
+	db, err := sqlite.Open(config.Conf.SQLite)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	reverted, err := sqlite.Rollback(db, steps)
+	if err != nil {
+		return err
+	}
+
+	version, err := sqlite.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"reverted": reverted,
+		"version":  version,
+	}).Info("Rollback finished")
+	return nil
+}
+
+// recoverMemory restores the latest snapshot into the trees and opens the
+// write-ahead log, whose records after the snapshot are replayed by the usecase
+func recoverMemory(trees map[constant.Gender]tree.Tree, matches matchstore.Store, reactions reactionstore.Store, reports reportstore.Store) (opts []usecase.PersonHandlerOption, walLog *wal.Log, replayed int, err error) {
+	var walSeq uint64
+	if config.Conf.Snapshot.Path != "" {
+		s, exist, err := snapshot.Load(config.Conf.Snapshot.Path)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if exist {
+			if err := s.Restore(trees, matches, reactions, reports); err != nil {
+				return nil, nil, 0, err
+			}
+			opts = append(opts, usecase.WithLastPersonID(s.LastPersonID), usecase.WithActivity(s.Activity))
+			walSeq = s.WALSeq
+
+			logrus.WithFields(logrus.Fields{
+				"takenAt":     s.TakenAt,
+				"personCount": len(s.Persons),
+				"matchCount":  len(s.Matches),
+			}).Info("Snapshot restored")
+		}
+	}
+
+	if config.Conf.WAL.Path == "" {
+		return opts, nil, 0, nil
+	}
+	if config.Conf.Snapshot.Path == "" {
+		logrus.Warning("WAL without snapshot is never compacted")
+	}
+
+	walLog, pending, err := wal.Open(config.Conf.WAL.Path, walSeq)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	opts = append(opts, usecase.WithWAL(walLog, pending))
+
+	logrus.WithFields(logrus.Fields{
+		"fromSeq":  walSeq,
+		"replayed": len(pending),
+	}).Info("WAL opened")
+
+	return opts, walLog, len(pending), nil
+}
+
+func compactOnBoot(ctx context.Context, h usecase.Handler) {
+	info, err := h.SaveSnapshot(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Compact wal failed")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"walSeq": info.WALSeq,
+	}).Info("WAL compacted")
+}
+
 func main() {
 	// Run the CLI app
 	if err := app.Run(os.Args); err != nil {
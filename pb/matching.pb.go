@@ -0,0 +1,1049 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: matching.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Latitude      float64                `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_matching_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Location) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *Location) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+type Person struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name   string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Height float64                `protobuf:"fixed64,3,opt,name=height,proto3" json:"height,omitempty"`
+	// male, female or non_binary
+	Gender      string   `protobuf:"bytes,4,opt,name=gender,proto3" json:"gender,omitempty"`
+	Seeking     []string `protobuf:"bytes,5,rep,name=seeking,proto3" json:"seeking,omitempty"`
+	WantedDates uint64   `protobuf:"varint,6,opt,name=wanted_dates,json=wantedDates,proto3" json:"wanted_dates,omitempty"`
+	// 0 when unknown
+	Age           int32     `protobuf:"varint,7,opt,name=age,proto3" json:"age,omitempty"`
+	City          string    `protobuf:"bytes,8,opt,name=city,proto3" json:"city,omitempty"`
+	Location      *Location `protobuf:"bytes,9,opt,name=location,proto3" json:"location,omitempty"`
+	Tags          []string  `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	Version       uint64    `protobuf:"varint,11,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Person) Reset() {
+	*x = Person{}
+	mi := &file_matching_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Person) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Person) ProtoMessage() {}
+
+func (x *Person) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Person.ProtoReflect.Descriptor instead.
+func (*Person) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Person) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Person) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Person) GetHeight() float64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Person) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *Person) GetSeeking() []string {
+	if x != nil {
+		return x.Seeking
+	}
+	return nil
+}
+
+func (x *Person) GetWantedDates() uint64 {
+	if x != nil {
+		return x.WantedDates
+	}
+	return 0
+}
+
+func (x *Person) GetAge() int32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+func (x *Person) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Person) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Person) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Person) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ScoreFactor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Weight        float64                `protobuf:"fixed64,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScoreFactor) Reset() {
+	*x = ScoreFactor{}
+	mi := &file_matching_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreFactor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreFactor) ProtoMessage() {}
+
+func (x *ScoreFactor) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreFactor.ProtoReflect.Descriptor instead.
+func (*ScoreFactor) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ScoreFactor) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ScoreFactor) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *ScoreFactor) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type Candidate struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Person *Person                `protobuf:"bytes,1,opt,name=person,proto3" json:"person,omitempty"`
+	// weighted mean of the factors in breakdown, from 0 to 1
+	Score         float64        `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Breakdown     []*ScoreFactor `protobuf:"bytes,3,rep,name=breakdown,proto3" json:"breakdown,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Candidate) Reset() {
+	*x = Candidate{}
+	mi := &file_matching_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Candidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Candidate) ProtoMessage() {}
+
+func (x *Candidate) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Candidate.ProtoReflect.Descriptor instead.
+func (*Candidate) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Candidate) GetPerson() *Person {
+	if x != nil {
+		return x.Person
+	}
+	return nil
+}
+
+func (x *Candidate) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Candidate) GetBreakdown() []*ScoreFactor {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+// CandidateFilter narrows the candidates down, zero fields do not filter
+type CandidateFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinAge        int32                  `protobuf:"varint,1,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`
+	MaxAge        int32                  `protobuf:"varint,2,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	City          string                 `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	MaxDistanceKm float64                `protobuf:"fixed64,5,opt,name=max_distance_km,json=maxDistanceKm,proto3" json:"max_distance_km,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CandidateFilter) Reset() {
+	*x = CandidateFilter{}
+	mi := &file_matching_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CandidateFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CandidateFilter) ProtoMessage() {}
+
+func (x *CandidateFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CandidateFilter.ProtoReflect.Descriptor instead.
+func (*CandidateFilter) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CandidateFilter) GetMinAge() int32 {
+	if x != nil {
+		return x.MinAge
+	}
+	return 0
+}
+
+func (x *CandidateFilter) GetMaxAge() int32 {
+	if x != nil {
+		return x.MaxAge
+	}
+	return 0
+}
+
+func (x *CandidateFilter) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *CandidateFilter) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CandidateFilter) GetMaxDistanceKm() float64 {
+	if x != nil {
+		return x.MaxDistanceKm
+	}
+	return 0
+}
+
+type Match struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	PersonId1     uint64                 `protobuf:"varint,2,opt,name=person_id1,json=personId1,proto3" json:"person_id1,omitempty"`
+	PersonId2     uint64                 `protobuf:"varint,3,opt,name=person_id2,json=personId2,proto3" json:"person_id2,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Match) Reset() {
+	*x = Match{}
+	mi := &file_matching_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Match) ProtoMessage() {}
+
+func (x *Match) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Match.ProtoReflect.Descriptor instead.
+func (*Match) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Match) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Match) GetPersonId1() uint64 {
+	if x != nil {
+		return x.PersonId1
+	}
+	return 0
+}
+
+func (x *Match) GetPersonId2() uint64 {
+	if x != nil {
+		return x.PersonId2
+	}
+	return 0
+}
+
+func (x *Match) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Match) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type AddPersonAndFindMatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id and version are ignored
+	Person        *Person `protobuf:"bytes,1,opt,name=person,proto3" json:"person,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddPersonAndFindMatchRequest) Reset() {
+	*x = AddPersonAndFindMatchRequest{}
+	mi := &file_matching_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddPersonAndFindMatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddPersonAndFindMatchRequest) ProtoMessage() {}
+
+func (x *AddPersonAndFindMatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddPersonAndFindMatchRequest.ProtoReflect.Descriptor instead.
+func (*AddPersonAndFindMatchRequest) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AddPersonAndFindMatchRequest) GetPerson() *Person {
+	if x != nil {
+		return x.Person
+	}
+	return nil
+}
+
+type AddPersonAndFindMatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matches       []*Person              `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddPersonAndFindMatchResponse) Reset() {
+	*x = AddPersonAndFindMatchResponse{}
+	mi := &file_matching_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddPersonAndFindMatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddPersonAndFindMatchResponse) ProtoMessage() {}
+
+func (x *AddPersonAndFindMatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddPersonAndFindMatchResponse.ProtoReflect.Descriptor instead.
+func (*AddPersonAndFindMatchResponse) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AddPersonAndFindMatchResponse) GetMatches() []*Person {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+type RemovePersonRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemovePersonRequest) Reset() {
+	*x = RemovePersonRequest{}
+	mi := &file_matching_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePersonRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePersonRequest) ProtoMessage() {}
+
+func (x *RemovePersonRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePersonRequest.ProtoReflect.Descriptor instead.
+func (*RemovePersonRequest) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RemovePersonRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type QuerySinglePeopleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Num   int32                  `protobuf:"varint,2,opt,name=num,proto3" json:"num,omitempty"`
+	// nearest (default), tallest, shortest or random
+	Sort string `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	// next of the previous page, empty for the first page
+	Cursor        string           `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Filter        *CandidateFilter `protobuf:"bytes,5,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuerySinglePeopleRequest) Reset() {
+	*x = QuerySinglePeopleRequest{}
+	mi := &file_matching_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuerySinglePeopleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuerySinglePeopleRequest) ProtoMessage() {}
+
+func (x *QuerySinglePeopleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuerySinglePeopleRequest.ProtoReflect.Descriptor instead.
+func (*QuerySinglePeopleRequest) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *QuerySinglePeopleRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *QuerySinglePeopleRequest) GetNum() int32 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *QuerySinglePeopleRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *QuerySinglePeopleRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *QuerySinglePeopleRequest) GetFilter() *CandidateFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type QuerySinglePeopleResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Candidates []*Candidate           `protobuf:"bytes,1,rep,name=candidates,proto3" json:"candidates,omitempty"`
+	// empty when there are no more candidates
+	Next          string `protobuf:"bytes,2,opt,name=next,proto3" json:"next,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuerySinglePeopleResponse) Reset() {
+	*x = QuerySinglePeopleResponse{}
+	mi := &file_matching_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuerySinglePeopleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuerySinglePeopleResponse) ProtoMessage() {}
+
+func (x *QuerySinglePeopleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuerySinglePeopleResponse.ProtoReflect.Descriptor instead.
+func (*QuerySinglePeopleResponse) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *QuerySinglePeopleResponse) GetCandidates() []*Candidate {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+func (x *QuerySinglePeopleResponse) GetNext() string {
+	if x != nil {
+		return x.Next
+	}
+	return ""
+}
+
+type MatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PersonId1     uint64                 `protobuf:"varint,1,opt,name=person_id1,json=personId1,proto3" json:"person_id1,omitempty"`
+	PersonId2     uint64                 `protobuf:"varint,2,opt,name=person_id2,json=personId2,proto3" json:"person_id2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchRequest) Reset() {
+	*x = MatchRequest{}
+	mi := &file_matching_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchRequest) ProtoMessage() {}
+
+func (x *MatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchRequest.ProtoReflect.Descriptor instead.
+func (*MatchRequest) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MatchRequest) GetPersonId1() uint64 {
+	if x != nil {
+		return x.PersonId1
+	}
+	return 0
+}
+
+func (x *MatchRequest) GetPersonId2() uint64 {
+	if x != nil {
+		return x.PersonId2
+	}
+	return 0
+}
+
+type MatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Match         *Match                 `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchResponse) Reset() {
+	*x = MatchResponse{}
+	mi := &file_matching_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchResponse) ProtoMessage() {}
+
+func (x *MatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchResponse.ProtoReflect.Descriptor instead.
+func (*MatchResponse) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MatchResponse) GetMatch() *Match {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+type WatchCandidatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Filter        *CandidateFilter       `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchCandidatesRequest) Reset() {
+	*x = WatchCandidatesRequest{}
+	mi := &file_matching_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchCandidatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCandidatesRequest) ProtoMessage() {}
+
+func (x *WatchCandidatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_matching_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCandidatesRequest.ProtoReflect.Descriptor instead.
+func (*WatchCandidatesRequest) Descriptor() ([]byte, []int) {
+	return file_matching_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchCandidatesRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *WatchCandidatesRequest) GetFilter() *CandidateFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+var File_matching_proto protoreflect.FileDescriptor
+
+const file_matching_proto_rawDesc = "" +
+	"\n" +
+	"\x0ematching.proto\x12\vmatching.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"D\n" +
+	"\bLocation\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\"\xa0\x02\n" +
+	"\x06Person\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x01R\x06height\x12\x16\n" +
+	"\x06gender\x18\x04 \x01(\tR\x06gender\x12\x18\n" +
+	"\aseeking\x18\x05 \x03(\tR\aseeking\x12!\n" +
+	"\fwanted_dates\x18\x06 \x01(\x04R\vwantedDates\x12\x10\n" +
+	"\x03age\x18\a \x01(\x05R\x03age\x12\x12\n" +
+	"\x04city\x18\b \x01(\tR\x04city\x121\n" +
+	"\blocation\x18\t \x01(\v2\x15.matching.v1.LocationR\blocation\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x18\n" +
+	"\aversion\x18\v \x01(\x04R\aversion\"O\n" +
+	"\vScoreFactor\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x01R\x06weight\"\x86\x01\n" +
+	"\tCandidate\x12+\n" +
+	"\x06person\x18\x01 \x01(\v2\x13.matching.v1.PersonR\x06person\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x126\n" +
+	"\tbreakdown\x18\x03 \x03(\v2\x18.matching.v1.ScoreFactorR\tbreakdown\"\x93\x01\n" +
+	"\x0fCandidateFilter\x12\x17\n" +
+	"\amin_age\x18\x01 \x01(\x05R\x06minAge\x12\x17\n" +
+	"\amax_age\x18\x02 \x01(\x05R\x06maxAge\x12\x12\n" +
+	"\x04city\x18\x03 \x01(\tR\x04city\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12&\n" +
+	"\x0fmax_distance_km\x18\x05 \x01(\x01R\rmaxDistanceKm\"\xa8\x01\n" +
+	"\x05Match\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"person_id1\x18\x02 \x01(\x04R\tpersonId1\x12\x1d\n" +
+	"\n" +
+	"person_id2\x18\x03 \x01(\x04R\tpersonId2\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"K\n" +
+	"\x1cAddPersonAndFindMatchRequest\x12+\n" +
+	"\x06person\x18\x01 \x01(\v2\x13.matching.v1.PersonR\x06person\"N\n" +
+	"\x1dAddPersonAndFindMatchResponse\x12-\n" +
+	"\amatches\x18\x01 \x03(\v2\x13.matching.v1.PersonR\amatches\"%\n" +
+	"\x13RemovePersonRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\x9e\x01\n" +
+	"\x18QuerySinglePeopleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x10\n" +
+	"\x03num\x18\x02 \x01(\x05R\x03num\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\x124\n" +
+	"\x06filter\x18\x05 \x01(\v2\x1c.matching.v1.CandidateFilterR\x06filter\"g\n" +
+	"\x19QuerySinglePeopleResponse\x126\n" +
+	"\n" +
+	"candidates\x18\x01 \x03(\v2\x16.matching.v1.CandidateR\n" +
+	"candidates\x12\x12\n" +
+	"\x04next\x18\x02 \x01(\tR\x04next\"L\n" +
+	"\fMatchRequest\x12\x1d\n" +
+	"\n" +
+	"person_id1\x18\x01 \x01(\x04R\tpersonId1\x12\x1d\n" +
+	"\n" +
+	"person_id2\x18\x02 \x01(\x04R\tpersonId2\"9\n" +
+	"\rMatchResponse\x12(\n" +
+	"\x05match\x18\x01 \x01(\v2\x12.matching.v1.MatchR\x05match\"^\n" +
+	"\x16WatchCandidatesRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x124\n" +
+	"\x06filter\x18\x02 \x01(\v2\x1c.matching.v1.CandidateFilterR\x06filter2\xba\x03\n" +
+	"\bMatching\x12n\n" +
+	"\x15AddPersonAndFindMatch\x12).matching.v1.AddPersonAndFindMatchRequest\x1a*.matching.v1.AddPersonAndFindMatchResponse\x12H\n" +
+	"\fRemovePerson\x12 .matching.v1.RemovePersonRequest\x1a\x16.google.protobuf.Empty\x12b\n" +
+	"\x11QuerySinglePeople\x12%.matching.v1.QuerySinglePeopleRequest\x1a&.matching.v1.QuerySinglePeopleResponse\x12>\n" +
+	"\x05Match\x12\x19.matching.v1.MatchRequest\x1a\x1a.matching.v1.MatchResponse\x12P\n" +
+	"\x0fWatchCandidates\x12#.matching.v1.WatchCandidatesRequest\x1a\x16.matching.v1.Candidate0\x01B'Z%github.com/ars0915/matching-system/pbb\x06proto3"
+
+var (
+	file_matching_proto_rawDescOnce sync.Once
+	file_matching_proto_rawDescData []byte
+)
+
+func file_matching_proto_rawDescGZIP() []byte {
+	file_matching_proto_rawDescOnce.Do(func() {
+		file_matching_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_matching_proto_rawDesc), len(file_matching_proto_rawDesc)))
+	})
+	return file_matching_proto_rawDescData
+}
+
+var file_matching_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_matching_proto_goTypes = []any{
+	(*Location)(nil),                      // 0: matching.v1.Location
+	(*Person)(nil),                        // 1: matching.v1.Person
+	(*ScoreFactor)(nil),                   // 2: matching.v1.ScoreFactor
+	(*Candidate)(nil),                     // 3: matching.v1.Candidate
+	(*CandidateFilter)(nil),               // 4: matching.v1.CandidateFilter
+	(*Match)(nil),                         // 5: matching.v1.Match
+	(*AddPersonAndFindMatchRequest)(nil),  // 6: matching.v1.AddPersonAndFindMatchRequest
+	(*AddPersonAndFindMatchResponse)(nil), // 7: matching.v1.AddPersonAndFindMatchResponse
+	(*RemovePersonRequest)(nil),           // 8: matching.v1.RemovePersonRequest
+	(*QuerySinglePeopleRequest)(nil),      // 9: matching.v1.QuerySinglePeopleRequest
+	(*QuerySinglePeopleResponse)(nil),     // 10: matching.v1.QuerySinglePeopleResponse
+	(*MatchRequest)(nil),                  // 11: matching.v1.MatchRequest
+	(*MatchResponse)(nil),                 // 12: matching.v1.MatchResponse
+	(*WatchCandidatesRequest)(nil),        // 13: matching.v1.WatchCandidatesRequest
+	(*timestamppb.Timestamp)(nil),         // 14: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                 // 15: google.protobuf.Empty
+}
+var file_matching_proto_depIdxs = []int32{
+	0,  // 0: matching.v1.Person.location:type_name -> matching.v1.Location
+	1,  // 1: matching.v1.Candidate.person:type_name -> matching.v1.Person
+	2,  // 2: matching.v1.Candidate.breakdown:type_name -> matching.v1.ScoreFactor
+	14, // 3: matching.v1.Match.created_at:type_name -> google.protobuf.Timestamp
+	1,  // 4: matching.v1.AddPersonAndFindMatchRequest.person:type_name -> matching.v1.Person
+	1,  // 5: matching.v1.AddPersonAndFindMatchResponse.matches:type_name -> matching.v1.Person
+	4,  // 6: matching.v1.QuerySinglePeopleRequest.filter:type_name -> matching.v1.CandidateFilter
+	3,  // 7: matching.v1.QuerySinglePeopleResponse.candidates:type_name -> matching.v1.Candidate
+	5,  // 8: matching.v1.MatchResponse.match:type_name -> matching.v1.Match
+	4,  // 9: matching.v1.WatchCandidatesRequest.filter:type_name -> matching.v1.CandidateFilter
+	6,  // 10: matching.v1.Matching.AddPersonAndFindMatch:input_type -> matching.v1.AddPersonAndFindMatchRequest
+	8,  // 11: matching.v1.Matching.RemovePerson:input_type -> matching.v1.RemovePersonRequest
+	9,  // 12: matching.v1.Matching.QuerySinglePeople:input_type -> matching.v1.QuerySinglePeopleRequest
+	11, // 13: matching.v1.Matching.Match:input_type -> matching.v1.MatchRequest
+	13, // 14: matching.v1.Matching.WatchCandidates:input_type -> matching.v1.WatchCandidatesRequest
+	7,  // 15: matching.v1.Matching.AddPersonAndFindMatch:output_type -> matching.v1.AddPersonAndFindMatchResponse
+	15, // 16: matching.v1.Matching.RemovePerson:output_type -> google.protobuf.Empty
+	10, // 17: matching.v1.Matching.QuerySinglePeople:output_type -> matching.v1.QuerySinglePeopleResponse
+	12, // 18: matching.v1.Matching.Match:output_type -> matching.v1.MatchResponse
+	3,  // 19: matching.v1.Matching.WatchCandidates:output_type -> matching.v1.Candidate
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_matching_proto_init() }
+func file_matching_proto_init() {
+	if File_matching_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_matching_proto_rawDesc), len(file_matching_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_matching_proto_goTypes,
+		DependencyIndexes: file_matching_proto_depIdxs,
+		MessageInfos:      file_matching_proto_msgTypes,
+	}.Build()
+	File_matching_proto = out.File
+	file_matching_proto_goTypes = nil
+	file_matching_proto_depIdxs = nil
+}
@@ -0,0 +1,296 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: matching.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Matching_AddPersonAndFindMatch_FullMethodName = "/matching.v1.Matching/AddPersonAndFindMatch"
+	Matching_RemovePerson_FullMethodName          = "/matching.v1.Matching/RemovePerson"
+	Matching_QuerySinglePeople_FullMethodName     = "/matching.v1.Matching/QuerySinglePeople"
+	Matching_Match_FullMethodName                 = "/matching.v1.Matching/Match"
+	Matching_WatchCandidates_FullMethodName       = "/matching.v1.Matching/WatchCandidates"
+)
+
+// MatchingClient is the client API for Matching service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Matching mirrors usecase.Person for the internal services. Errors carry the
+// code of the API error in an ErrorInfo detail, and the invalid fields of a
+// request in a BadRequest detail.
+type MatchingClient interface {
+	// AddPersonAndFindMatch adds the person and returns the nearest candidate
+	AddPersonAndFindMatch(ctx context.Context, in *AddPersonAndFindMatchRequest, opts ...grpc.CallOption) (*AddPersonAndFindMatchResponse, error)
+	RemovePerson(ctx context.Context, in *RemovePersonRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// QuerySinglePeople returns a page of the candidates of the person
+	QuerySinglePeople(ctx context.Context, in *QuerySinglePeopleRequest, opts ...grpc.CallOption) (*QuerySinglePeopleResponse, error)
+	// Match pairs two people who have liked each other
+	Match(ctx context.Context, in *MatchRequest, opts ...grpc.CallOption) (*MatchResponse, error)
+	// WatchCandidates sends the current candidates of the person, then each new
+	// one as it appears, until the person is removed or the client cancels
+	WatchCandidates(ctx context.Context, in *WatchCandidatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Candidate], error)
+}
+
+type matchingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMatchingClient(cc grpc.ClientConnInterface) MatchingClient {
+	return &matchingClient{cc}
+}
+
+func (c *matchingClient) AddPersonAndFindMatch(ctx context.Context, in *AddPersonAndFindMatchRequest, opts ...grpc.CallOption) (*AddPersonAndFindMatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddPersonAndFindMatchResponse)
+	err := c.cc.Invoke(ctx, Matching_AddPersonAndFindMatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchingClient) RemovePerson(ctx context.Context, in *RemovePersonRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Matching_RemovePerson_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchingClient) QuerySinglePeople(ctx context.Context, in *QuerySinglePeopleRequest, opts ...grpc.CallOption) (*QuerySinglePeopleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuerySinglePeopleResponse)
+	err := c.cc.Invoke(ctx, Matching_QuerySinglePeople_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchingClient) Match(ctx context.Context, in *MatchRequest, opts ...grpc.CallOption) (*MatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MatchResponse)
+	err := c.cc.Invoke(ctx, Matching_Match_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchingClient) WatchCandidates(ctx context.Context, in *WatchCandidatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Candidate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Matching_ServiceDesc.Streams[0], Matching_WatchCandidates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCandidatesRequest, Candidate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Matching_WatchCandidatesClient = grpc.ServerStreamingClient[Candidate]
+
+// MatchingServer is the server API for Matching service.
+// All implementations must embed UnimplementedMatchingServer
+// for forward compatibility.
+//
+// Matching mirrors usecase.Person for the internal services. Errors carry the
+// code of the API error in an ErrorInfo detail, and the invalid fields of a
+// request in a BadRequest detail.
+type MatchingServer interface {
+	// AddPersonAndFindMatch adds the person and returns the nearest candidate
+	AddPersonAndFindMatch(context.Context, *AddPersonAndFindMatchRequest) (*AddPersonAndFindMatchResponse, error)
+	RemovePerson(context.Context, *RemovePersonRequest) (*emptypb.Empty, error)
+	// QuerySinglePeople returns a page of the candidates of the person
+	QuerySinglePeople(context.Context, *QuerySinglePeopleRequest) (*QuerySinglePeopleResponse, error)
+	// Match pairs two people who have liked each other
+	Match(context.Context, *MatchRequest) (*MatchResponse, error)
+	// WatchCandidates sends the current candidates of the person, then each new
+	// one as it appears, until the person is removed or the client cancels
+	WatchCandidates(*WatchCandidatesRequest, grpc.ServerStreamingServer[Candidate]) error
+	mustEmbedUnimplementedMatchingServer()
+}
+
+// UnimplementedMatchingServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMatchingServer struct{}
+
+func (UnimplementedMatchingServer) AddPersonAndFindMatch(context.Context, *AddPersonAndFindMatchRequest) (*AddPersonAndFindMatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPersonAndFindMatch not implemented")
+}
+func (UnimplementedMatchingServer) RemovePerson(context.Context, *RemovePersonRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePerson not implemented")
+}
+func (UnimplementedMatchingServer) QuerySinglePeople(context.Context, *QuerySinglePeopleRequest) (*QuerySinglePeopleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuerySinglePeople not implemented")
+}
+func (UnimplementedMatchingServer) Match(context.Context, *MatchRequest) (*MatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Match not implemented")
+}
+func (UnimplementedMatchingServer) WatchCandidates(*WatchCandidatesRequest, grpc.ServerStreamingServer[Candidate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCandidates not implemented")
+}
+func (UnimplementedMatchingServer) mustEmbedUnimplementedMatchingServer() {}
+func (UnimplementedMatchingServer) testEmbeddedByValue()                  {}
+
+// UnsafeMatchingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MatchingServer will
+// result in compilation errors.
+type UnsafeMatchingServer interface {
+	mustEmbedUnimplementedMatchingServer()
+}
+
+func RegisterMatchingServer(s grpc.ServiceRegistrar, srv MatchingServer) {
+	// If the following call pancis, it indicates UnimplementedMatchingServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Matching_ServiceDesc, srv)
+}
+
+func _Matching_AddPersonAndFindMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPersonAndFindMatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchingServer).AddPersonAndFindMatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Matching_AddPersonAndFindMatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchingServer).AddPersonAndFindMatch(ctx, req.(*AddPersonAndFindMatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Matching_RemovePerson_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePersonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchingServer).RemovePerson(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Matching_RemovePerson_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchingServer).RemovePerson(ctx, req.(*RemovePersonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Matching_QuerySinglePeople_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuerySinglePeopleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchingServer).QuerySinglePeople(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Matching_QuerySinglePeople_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchingServer).QuerySinglePeople(ctx, req.(*QuerySinglePeopleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Matching_Match_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchingServer).Match(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Matching_Match_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchingServer).Match(ctx, req.(*MatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Matching_WatchCandidates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCandidatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MatchingServer).WatchCandidates(m, &grpc.GenericServerStream[WatchCandidatesRequest, Candidate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Matching_WatchCandidatesServer = grpc.ServerStreamingServer[Candidate]
+
+// Matching_ServiceDesc is the grpc.ServiceDesc for Matching service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Matching_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "matching.v1.Matching",
+	HandlerType: (*MatchingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddPersonAndFindMatch",
+			Handler:    _Matching_AddPersonAndFindMatch_Handler,
+		},
+		{
+			MethodName: "RemovePerson",
+			Handler:    _Matching_RemovePerson_Handler,
+		},
+		{
+			MethodName: "QuerySinglePeople",
+			Handler:    _Matching_QuerySinglePeople_Handler,
+		},
+		{
+			MethodName: "Match",
+			Handler:    _Matching_Match_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCandidates",
+			Handler:       _Matching_WatchCandidates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "matching.proto",
+}
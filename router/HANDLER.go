@@ -1,6 +1,8 @@
 package router
 
 import (
+	"context"
+
 	"github.com/ars0915/matching-system/config"
 	"github.com/ars0915/matching-system/usecase"
 )
@@ -8,12 +10,19 @@ import (
 type HttpHandler struct {
 	conf config.ConfENV
 	h    usecase.Handler
+
+	// streams is cancelled when the server shuts down, which ends the long-lived responses
+	streams     context.Context
+	stopStreams context.CancelFunc
 }
 
 func newHttpHandler(conf config.ConfENV, h usecase.Handler) *HttpHandler {
+	streams, stopStreams := context.WithCancel(context.Background())
 	return &HttpHandler{
-		conf: conf,
-		h:    h,
+		conf:        conf,
+		h:           h,
+		streams:     streams,
+		stopStreams: stopStreams,
 	}
 }
 
@@ -23,10 +32,12 @@ func (rH *HttpHandler) Usecase() usecase.Handler {
 
 type Handler struct {
 	http *HttpHandler
+	grpc *GrpcHandler
 }
 
 func NewHandler(conf config.ConfENV, h usecase.Handler) Handler {
 	return Handler{
 		http: newHttpHandler(conf, h),
+		grpc: newGrpcHandler(h),
 	}
 }
@@ -0,0 +1,46 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+func (rH *HttpHandler) saveSnapshotHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	data, err := rH.h.SaveSnapshot(ctx)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
+
+type runRoundBody struct {
+	Commit bool `json:"commit"`
+}
+
+func (rH *HttpHandler) runRoundHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	// An empty body is a dry run
+	var body runRoundBody
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+			return
+		}
+	}
+
+	data, err := rH.h.RunRound(ctx, body.Commit)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
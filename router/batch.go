@@ -0,0 +1,129 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/usecase"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+// addPersonsBody keeps the persons raw so that each one is bound on its own and an
+// invalid person only fails its own item
+type addPersonsBody struct {
+	Persons []json.RawMessage `json:"persons" binding:"required"`
+}
+
+type removePersonsBody struct {
+	IDs []uint64 `json:"ids" binding:"required"`
+}
+
+// matchPairsBody leaves the pairs to the usecase, which validates each one on its own
+// so an invalid pair only fails its own item
+type matchPairsBody struct {
+	Pairs []matchBody `json:"pairs" binding:"required"`
+}
+
+// batchItem is the result of one item, ID is set on success and the rest on failure
+type batchItem struct {
+	ID      uint64            `json:"id,omitempty"`
+	Code    int               `json:"code,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Errors  []cGin.FieldError `json:"errors,omitempty"`
+}
+
+func newBatchItems(results []usecase.BulkResult) []batchItem {
+	items := make([]batchItem, len(results))
+	for i, r := range results {
+		if r.Err == nil {
+			items[i] = batchItem{ID: r.ID}
+			continue
+		}
+		code, msg, fields := cGin.ErrorMeta(r.Err, http.StatusInternalServerError, "Internal Server Error")
+		items[i] = batchItem{Code: code, Message: msg, Errors: fields}
+	}
+	return items
+}
+
+func (rH *HttpHandler) addPersonsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	var body addPersonsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(cGin.BindingError(usecase.ErrorInvalidPerson, err)).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	// the persons that fail to bind are left out of the usecase call and merged back after
+	var (
+		persons []entity.Person
+		indexes []int
+	)
+	items := make([]batchItem, len(body.Persons))
+	for i, raw := range body.Persons {
+		var p addPersonBody
+		if err := binding.JSON.BindBody(raw, &p); err != nil {
+			code, msg, fields := cGin.ErrorMeta(cGin.BindingError(usecase.ErrorInvalidPerson, err), http.StatusBadRequest, "Invalid Json")
+			items[i] = batchItem{Code: code, Message: msg, Errors: fields}
+			continue
+		}
+		persons = append(persons, p.v2().person())
+		indexes = append(indexes, i)
+	}
+
+	results, err := rH.h.AddPersons(ctx, persons)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	for j, item := range newBatchItems(results) {
+		items[indexes[j]] = item
+	}
+
+	ctx.WithData(items).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) removePersonsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	var body removePersonsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	results, err := rH.h.RemovePersons(ctx, body.IDs)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(newBatchItems(results)).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) matchPairsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	var body matchPairsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	pairs := make([]usecase.PersonPair, len(body.Pairs))
+	for i, p := range body.Pairs {
+		pairs[i] = usecase.PersonPair{ID1: p.Id1, ID2: p.Id2}
+	}
+
+	results, err := rH.h.MatchPairs(ctx, pairs)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(newBatchItems(results)).Response(http.StatusOK, "")
+}
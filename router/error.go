@@ -0,0 +1,16 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/usecase"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+func (rH *HttpHandler) listErrorsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	ctx.WithData(usecase.ErrorCatalogue()).Response(http.StatusOK, "")
+}
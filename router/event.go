@@ -0,0 +1,65 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+// eventKeepAlive is how often an idle event stream sends a comment, so proxies keep it open
+const eventKeepAlive = 30 * time.Second
+
+// personEventsHandler streams the events of the person as Server-Sent Events named
+// after the event type. The stream ends after the removed event, when the client
+// leaves or falls behind, or when the server shuts down.
+func (rH *HttpHandler) personEventsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	streamCtx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stop := context.AfterFunc(rH.streams, cancel)
+	defer stop()
+
+	events, err := rH.h.SubscribeEvents(streamCtx, uint64(id))
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// Send the headers now rather than with the first event
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(ev.Type), ev)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
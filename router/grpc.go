@@ -0,0 +1,186 @@
+package router
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/pb"
+	"github.com/ars0915/matching-system/usecase"
+	"github.com/ars0915/matching-system/util/cGin"
+	"github.com/ars0915/matching-system/util/cGrpc"
+)
+
+// GrpcHandler serves usecase.Person over gRPC, the errors are converted by cGrpc's interceptors
+type GrpcHandler struct {
+	pb.UnimplementedMatchingServer
+	h usecase.Handler
+}
+
+// grpcCodes are the errors whose HTTP status alone does not tell the gRPC code, every
+// 409 Conflict but a person that exists is a state the request does not fit
+var grpcCodes = map[cGin.CustomError]codes.Code{
+	usecase.ErrorPersonExist:     codes.AlreadyExists,
+	usecase.ErrorVersionConflict: codes.Aborted,
+	usecase.ErrorReportResolved:  codes.FailedPrecondition,
+	usecase.ErrorPersonSuspended: codes.FailedPrecondition,
+	usecase.ErrorAlreadyMatched:  codes.FailedPrecondition,
+}
+
+func init() {
+	for cErr, code := range grpcCodes {
+		cGrpc.SetCode(cErr, code)
+	}
+}
+
+func newGrpcHandler(h usecase.Handler) *GrpcHandler {
+	return &GrpcHandler{
+		h: h,
+	}
+}
+
+func (g *GrpcHandler) AddPersonAndFindMatch(ctx context.Context, req *pb.AddPersonAndFindMatchRequest) (*pb.AddPersonAndFindMatchResponse, error) {
+	people, err := g.h.AddPersonAndFindMatch(ctx, personFromPB(req.GetPerson()))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.AddPersonAndFindMatchResponse{Matches: make([]*pb.Person, len(people))}
+	for i, p := range people {
+		resp.Matches[i] = personToPB(p)
+	}
+	return resp, nil
+}
+
+func (g *GrpcHandler) RemovePerson(ctx context.Context, req *pb.RemovePersonRequest) (*emptypb.Empty, error) {
+	if err := g.h.RemovePerson(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (g *GrpcHandler) QuerySinglePeople(ctx context.Context, req *pb.QuerySinglePeopleRequest) (*pb.QuerySinglePeopleResponse, error) {
+	page, err := g.h.QuerySinglePeople(ctx, req.GetId(), usecase.CandidateQuery{
+		Num:    int(req.GetNum()),
+		Sort:   constant.CandidateSort(req.GetSort()),
+		Cursor: req.GetCursor(),
+		Filter: candidateFilterFromPB(req.GetFilter()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.QuerySinglePeopleResponse{
+		Candidates: make([]*pb.Candidate, len(page.Candidates)),
+		Next:       page.Next,
+	}
+	for i, c := range page.Candidates {
+		resp.Candidates[i] = candidateToPB(c)
+	}
+	return resp, nil
+}
+
+func (g *GrpcHandler) Match(ctx context.Context, req *pb.MatchRequest) (*pb.MatchResponse, error) {
+	m, err := g.h.Match(ctx, req.GetPersonId1(), req.GetPersonId2())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.MatchResponse{Match: &pb.Match{
+		Id:        m.ID,
+		PersonId1: m.PersonID1,
+		PersonId2: m.PersonID2,
+		Status:    string(m.Status),
+		CreatedAt: timestamppb.New(m.CreatedAt),
+	}}, nil
+}
+
+// WatchCandidates streams the current candidates and then each one who joins. The stream
+// ends with the error of the watch, NotFound once the person is removed.
+func (g *GrpcHandler) WatchCandidates(req *pb.WatchCandidatesRequest, stream pb.Matching_WatchCandidatesServer) error {
+	return g.h.WatchCandidates(stream.Context(), req.GetId(), candidateFilterFromPB(req.GetFilter()), func(c usecase.Candidate) error {
+		return stream.Send(candidateToPB(c))
+	})
+}
+
+func personFromPB(p *pb.Person) entity.Person {
+	seeking := make([]constant.Gender, len(p.GetSeeking()))
+	for i, g := range p.GetSeeking() {
+		seeking[i] = constant.Gender(g)
+	}
+
+	var location *entity.Location
+	if l := p.GetLocation(); l != nil {
+		location = &entity.Location{Latitude: l.GetLatitude(), Longitude: l.GetLongitude()}
+	}
+
+	var wantedDates *uint64
+	if p.GetWantedDates() > 0 {
+		dates := p.GetWantedDates()
+		wantedDates = &dates
+	}
+
+	return entity.Person{
+		Name:        p.GetName(),
+		Height:      p.GetHeight(),
+		Gender:      constant.Gender(p.GetGender()),
+		Seeking:     seeking,
+		WantedDates: wantedDates,
+		Age:         int(p.GetAge()),
+		City:        p.GetCity(),
+		Location:    location,
+		Tags:        p.GetTags(),
+	}
+}
+
+func personToPB(p entity.Person) *pb.Person {
+	seeking := make([]string, len(p.Seeking))
+	for i, g := range p.Seeking {
+		seeking[i] = string(g)
+	}
+
+	result := &pb.Person{
+		Id:      p.ID,
+		Name:    p.Name,
+		Height:  p.Height,
+		Gender:  string(p.Gender),
+		Seeking: seeking,
+		Age:     int32(p.Age),
+		City:    p.City,
+		Tags:    p.Tags,
+		Version: p.Version,
+	}
+	if p.WantedDates != nil {
+		result.WantedDates = *p.WantedDates
+	}
+	if p.Location != nil {
+		result.Location = &pb.Location{Latitude: p.Location.Latitude, Longitude: p.Location.Longitude}
+	}
+	return result
+}
+
+func candidateToPB(c usecase.Candidate) *pb.Candidate {
+	result := &pb.Candidate{
+		Person:    personToPB(c.Person),
+		Score:     c.Score,
+		Breakdown: make([]*pb.ScoreFactor, len(c.Breakdown)),
+	}
+	for i, f := range c.Breakdown {
+		result.Breakdown[i] = &pb.ScoreFactor{Name: f.Name, Score: f.Score, Weight: f.Weight}
+	}
+	return result
+}
+
+func candidateFilterFromPB(f *pb.CandidateFilter) usecase.CandidateFilter {
+	return usecase.CandidateFilter{
+		MinAge:        int(f.GetMinAge()),
+		MaxAge:        int(f.GetMaxAge()),
+		City:          f.GetCity(),
+		Tags:          f.GetTags(),
+		MaxDistanceKm: f.GetMaxDistanceKm(),
+	}
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ars0915/matching-system/pb"
+	"github.com/ars0915/matching-system/usecase"
+	"github.com/ars0915/matching-system/util/cGin"
+	"github.com/ars0915/matching-system/util/cGrpc"
+)
+
+// newTestGrpcClient serves h over an in-memory connection with the interceptors of RunServer
+func newTestGrpcClient(t *testing.T) pb.MatchingClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(cGrpc.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(cGrpc.StreamServerInterceptor),
+	)
+	pb.RegisterMatchingServer(srv, newGrpcHandler(newTestUsecase()))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewMatchingClient(conn)
+}
+
+func Test_GrpcAddPersonNonFinite(t *testing.T) {
+	client := newTestGrpcClient(t)
+
+	// JSON cannot carry NaN or Inf but protobuf doubles can
+	for _, p := range []*pb.Person{
+		{Name: "a", Height: math.NaN(), Gender: "male", WantedDates: 1},
+		{Name: "a", Height: math.Inf(1), Gender: "male", WantedDates: 1},
+		{Name: "a", Height: 170, Gender: "male", WantedDates: 1, Location: &pb.Location{Latitude: math.NaN()}},
+	} {
+		_, err := client.AddPersonAndFindMatch(context.Background(), &pb.AddPersonAndFindMatchRequest{Person: p})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	_, err := client.AddPersonAndFindMatch(context.Background(), &pb.AddPersonAndFindMatchRequest{
+		Person: &pb.Person{Name: "a", Height: 170, Gender: "male", WantedDates: 1},
+	})
+	assert.Nil(t, err)
+}
+
+func Test_GrpcCodes(t *testing.T) {
+	tests := []struct {
+		err  cGin.CustomError
+		want codes.Code
+	}{
+		{usecase.ErrorPersonExist, codes.AlreadyExists},
+		{usecase.ErrorVersionConflict, codes.Aborted},
+		{usecase.ErrorReportResolved, codes.FailedPrecondition},
+		{usecase.ErrorPersonSuspended, codes.FailedPrecondition},
+		{usecase.ErrorAlreadyMatched, codes.FailedPrecondition},
+		{usecase.ErrorPersonNotFound, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, status.Code(cGrpc.Status(tt.err)), tt.err.Message)
+	}
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/usecase"
+)
+
+// newTestUsecase returns the usecases on empty in-memory stores, without snapshots
+func newTestUsecase() usecase.Handler {
+	trees := map[constant.Gender]tree.Tree{}
+	for _, g := range constant.Genders {
+		trees[g] = tree.NewPersonTree()
+	}
+	return usecase.InitHandler(trees, matchstore.NewMatchStore(), reactionstore.NewReactionStore(), reportstore.NewReportStore(), "")
+}
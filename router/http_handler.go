@@ -17,6 +17,40 @@ func (rH HttpHandler) getRouter() (routes []appRouter) {
 		{http.MethodPost, "/addPersonAndFindMatch/", rH.addPersonAndFindMatchHandler},
 		{http.MethodDelete, "/removeSinglePerson/:id/", rH.removePersonHandler},
 		{http.MethodGet, "/querySinglePeople/:id/", rH.querySinglePeopleHandler},
+		{http.MethodGet, "/persons/", rH.listPersonsHandler},
+		{http.MethodGet, "/persons/:id/", rH.getPersonHandler},
+		{http.MethodPatch, "/persons/:id/", rH.updatePersonHandler},
 		{http.MethodPost, "/match/", rH.matchHandler},
+		{http.MethodPost, "/persons/:id/likes/", rH.likeHandler},
+		{http.MethodPost, "/persons/:id/passes/", rH.passHandler},
+		{http.MethodPost, "/persons/:id/blocks/", rH.blockHandler},
+		{http.MethodDelete, "/persons/:id/blocks/:candidateId/", rH.unblockHandler},
+		{http.MethodPost, "/persons/:id/reports/", rH.reportHandler},
+		{http.MethodGet, "/persons/:id/events/", rH.personEventsHandler},
+
+		{http.MethodGet, "/matches/", rH.listMatchesHandler},
+		{http.MethodGet, "/matches/:id/", rH.getMatchHandler},
+		{http.MethodGet, "/persons/:id/matches/", rH.listPersonMatchesHandler},
+
+		{http.MethodPost, "/batch/persons/", rH.addPersonsHandler},
+		{http.MethodPost, "/batch/persons/remove/", rH.removePersonsHandler},
+		{http.MethodPost, "/batch/matches/", rH.matchPairsHandler},
+
+		{http.MethodPost, "/admin/snapshots/", rH.saveSnapshotHandler},
+		{http.MethodPost, "/admin/rounds/", rH.runRoundHandler},
+		{http.MethodGet, "/admin/reports/", rH.listReportsHandler},
+		{http.MethodPost, "/admin/reports/:id/dismiss/", rH.dismissReportHandler},
+		{http.MethodPost, "/admin/persons/:id/suspension/", rH.suspendPersonHandler},
+		{http.MethodDelete, "/admin/persons/:id/suspension/", rH.unsuspendPersonHandler},
+
+		{http.MethodGet, "/errors/", rH.listErrorsHandler},
+
+		// v2 names resources rather than actions and serialises fields in camelCase,
+		// the routes above are kept as adapters over the same handlers
+		{http.MethodPost, "/v2/persons", rH.createPersonV2Handler},
+		{http.MethodGet, "/v2/persons/:id", rH.getPersonV2Handler},
+		{http.MethodDelete, "/v2/persons/:id", rH.removePersonHandler},
+		{http.MethodGet, "/v2/persons/:id/candidates", rH.listCandidatesV2Handler},
+		{http.MethodPost, "/v2/matches", rH.createMatchV2Handler},
 	}
 }
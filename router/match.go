@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+func (rH *HttpHandler) listMatchesHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	page := ctx.GetPaginator()
+	data, total, err := rH.h.ListMatches(ctx, page)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	page.SetTotalCount(total)
+
+	ctx.WithData(data).WithPaginator(page).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) getMatchHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	data, err := rH.h.GetMatch(ctx, uint64(id))
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) listPersonMatchesHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	page := ctx.GetPaginator()
+	data, total, err := rH.h.ListPersonMatches(ctx, uint64(id), page)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	page.SetTotalCount(total)
+
+	ctx.WithData(data).WithPaginator(page).Response(http.StatusOK, "")
+}
@@ -0,0 +1,81 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+func (rH *HttpHandler) listReportsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	page := ctx.GetPaginator()
+	status := constant.ReportStatus(ctx.Query("status"))
+	data, total, err := rH.h.ListReports(ctx, status, page)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	page.SetTotalCount(total)
+
+	ctx.WithData(data).WithPaginator(page).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) dismissReportHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	data, err := rH.h.DismissReport(ctx, uint64(id))
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) suspendPersonHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	if err = rH.h.SuspendPerson(ctx, uint64(id)); err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) unsuspendPersonHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	if err = rH.h.UnsuspendPerson(ctx, uint64(id)); err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.Response(http.StatusOK, "")
+}
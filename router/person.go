@@ -3,19 +3,56 @@ package router
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/ars0915/matching-system/constant"
 	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/usecase"
 	"github.com/ars0915/matching-system/util/cGin"
 )
 
 type addPersonBody struct {
-	Name       string  `json:"name" binding:"required"`
-	Height     float64 `json:"height" binding:"required"`
-	Gender     string  `json:"gender" binding:"required"`
-	WantedDate *uint64 `json:"wantedDate" binding:"required"`
+	Name       string        `json:"name" binding:"required"`
+	Height     float64       `json:"height" binding:"required,gt=0"`
+	Gender     string        `json:"gender" binding:"required,oneof=male female non_binary"`
+	Seeking    []string      `json:"seeking" binding:"omitempty,dive,oneof=male female non_binary"`
+	WantedDate *uint64       `json:"wantedDate" binding:"required,gt=0"`
+	Age        int           `json:"age"`
+	City       string        `json:"city"`
+	Location   *locationBody `json:"location"`
+	Tags       []string      `json:"tags"`
+}
+
+// updatePersonBody leaves out the fields that do not change
+type updatePersonBody struct {
+	Name       *string  `json:"name"`
+	Height     *float64 `json:"height"`
+	Gender     *string  `json:"gender" binding:"omitempty,oneof=male female non_binary"`
+	Seeking    []string `json:"seeking" binding:"omitempty,dive,oneof=male female non_binary"`
+	WantedDate *uint64  `json:"wantedDate"`
+	Version    *uint64  `json:"version" binding:"required"`
+}
+
+// v2 converts the body to the one of POST /v2/persons, which names wantedDates in plural
+func (b addPersonBody) v2() personBody {
+	return personBody{
+		Name:        b.Name,
+		Height:      b.Height,
+		Gender:      b.Gender,
+		Seeking:     b.Seeking,
+		WantedDates: b.WantedDate,
+		Age:         b.Age,
+		City:        b.City,
+		Location:    b.Location,
+		Tags:        b.Tags,
+	}
+}
+
+type locationBody struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 func (rH *HttpHandler) addPersonAndFindMatchHandler(c *gin.Context) {
@@ -23,16 +60,11 @@ func (rH *HttpHandler) addPersonAndFindMatchHandler(c *gin.Context) {
 
 	var body addPersonBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		ctx.WithError(cGin.BindingError(usecase.ErrorInvalidPerson, err)).Response(http.StatusBadRequest, "Invalid Json")
 		return
 	}
 
-	data, err := rH.h.AddPersonAndFindMatch(ctx, entity.Person{
-		Name:        body.Name,
-		Height:      body.Height,
-		Gender:      constant.Gender(body.Gender),
-		WantedDates: body.WantedDate,
-	})
+	data, err := rH.h.AddPersonAndFindMatch(ctx, body.v2().person())
 	if err != nil {
 		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
 		return
@@ -59,6 +91,98 @@ func (rH *HttpHandler) removePersonHandler(c *gin.Context) {
 	ctx.Response(http.StatusOK, "")
 }
 
+func (rH *HttpHandler) getPersonHandler(c *gin.Context) {
+	rH.getPerson(cGin.NewContext(c), func(p entity.Person) interface{} {
+		return p
+	})
+}
+
+// getPerson responds the person of the id param, present shapes it for the API version
+func (rH *HttpHandler) getPerson(ctx *cGin.Context, present func(entity.Person) interface{}) {
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	data, err := rH.h.GetPerson(ctx, uint64(id))
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(present(data)).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) listPersonsHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	q := usecase.PersonQuery{Gender: constant.Gender(ctx.Query("gender"))}
+	var err error
+	if s := ctx.Query("minHeight"); s != "" {
+		if q.MinHeight, err = strconv.ParseFloat(s, 64); err != nil {
+			ctx.WithError(usecase.ErrorInvalidFilter).Response(http.StatusBadRequest, "Invalid filter")
+			return
+		}
+	}
+	if s := ctx.Query("maxHeight"); s != "" {
+		if q.MaxHeight, err = strconv.ParseFloat(s, 64); err != nil {
+			ctx.WithError(usecase.ErrorInvalidFilter).Response(http.StatusBadRequest, "Invalid filter")
+			return
+		}
+	}
+
+	page := ctx.GetPaginator()
+	data, total, err := rH.h.ListPersons(ctx, q, page)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	page.SetTotalCount(total)
+
+	ctx.WithData(data).WithPaginator(page).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) updatePersonHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	var body updatePersonBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(cGin.BindingError(usecase.ErrorInvalidPerson, err)).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	patch := entity.PersonPatch{
+		Name:        body.Name,
+		Height:      body.Height,
+		WantedDates: body.WantedDate,
+		Version:     *body.Version,
+	}
+	if body.Gender != nil {
+		gender := constant.Gender(*body.Gender)
+		patch.Gender = &gender
+	}
+	for _, g := range body.Seeking {
+		patch.Seeking = append(patch.Seeking, constant.Gender(g))
+	}
+
+	data, err := rH.h.UpdatePerson(ctx, uint64(id), patch)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
+
 func (rH *HttpHandler) querySinglePeopleHandler(c *gin.Context) {
 	ctx := cGin.NewContext(c)
 
@@ -76,13 +200,60 @@ func (rH *HttpHandler) querySinglePeopleHandler(c *gin.Context) {
 		return
 	}
 
-	data, err := rH.h.QuerySinglePeople(ctx, uint64(id), queryNum)
+	rH.candidates(ctx, uint64(id), queryNum, func(candidates []usecase.Candidate) interface{} {
+		return candidates
+	})
+}
+
+// candidates responds a page of the candidates of id, present shapes them for the API version
+func (rH *HttpHandler) candidates(ctx *cGin.Context, id uint64, num int, present func([]usecase.Candidate) interface{}) {
+	filter, err := candidateFilter(ctx)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid filter")
+		return
+	}
+
+	page, err := rH.h.QuerySinglePeople(ctx, id, usecase.CandidateQuery{
+		Num:    num,
+		Sort:   constant.CandidateSort(ctx.Query("sort")),
+		Cursor: ctx.Query("cursor"),
+		Filter: filter,
+	})
 	if err != nil {
 		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	ctx.WithData(data).Response(http.StatusOK, "")
+	ctx.WithNext(page.Next).WithData(present(page.Candidates)).Response(http.StatusOK, "")
+}
+
+// candidateFilter reads the optional minAge, maxAge, city, tags and maxDistance query parameters
+func candidateFilter(ctx *cGin.Context) (usecase.CandidateFilter, error) {
+	var (
+		filter = usecase.CandidateFilter{City: ctx.Query("city")}
+		err    error
+	)
+
+	if s := ctx.Query("minAge"); s != "" {
+		if filter.MinAge, err = strconv.Atoi(s); err != nil {
+			return filter, usecase.ErrorInvalidFilter
+		}
+	}
+	if s := ctx.Query("maxAge"); s != "" {
+		if filter.MaxAge, err = strconv.Atoi(s); err != nil {
+			return filter, usecase.ErrorInvalidFilter
+		}
+	}
+	if s := ctx.Query("maxDistance"); s != "" {
+		if filter.MaxDistanceKm, err = strconv.ParseFloat(s, 64); err != nil {
+			return filter, usecase.ErrorInvalidFilter
+		}
+	}
+	if s := ctx.Query("tags"); s != "" {
+		filter.Tags = strings.Split(s, ",")
+	}
+
+	return filter, nil
 }
 
 type matchBody struct {
@@ -99,10 +270,18 @@ func (rH *HttpHandler) matchHandler(c *gin.Context) {
 		return
 	}
 
-	if err := rH.h.Match(ctx, body.Id1, body.Id2); err != nil {
+	rH.match(ctx, body.Id1, body.Id2, http.StatusOK, func(m entity.Match) interface{} {
+		return m
+	})
+}
+
+// match responds the match of id1 and id2 with httpCode, present shapes it for the API version
+func (rH *HttpHandler) match(ctx *cGin.Context, id1, id2 uint64, httpCode int, present func(entity.Match) interface{}) {
+	data, err := rH.h.Match(ctx, id1, id2)
+	if err != nil {
 		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	ctx.Response(http.StatusOK, "")
+	ctx.WithData(present(data)).Response(httpCode, "")
 }
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/config"
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func Test_ListPersonsLimit(t *testing.T) {
+	h := newTestUsecase()
+	dates := uint64(1)
+	for i := 0; i < paging.DefaultLimit+5; i++ {
+		_, err := h.AddPersonAndFindMatch(context.Background(), entity.Person{
+			Name: "a", Height: 170, Gender: constant.GenderMale, WantedDates: &dates,
+		})
+		assert.Nil(t, err)
+	}
+	engine := newHttpHandler(config.ConfENV{}, h).routerEngine()
+
+	// a request cannot ask for every person at once, only the snapshot can
+	for _, limit := range []string{"-1", "0", "abc"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/persons/?limit="+limit, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Meta paging.Paginator `json:"meta"`
+			Data []entity.Person  `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Data, paging.DefaultLimit, limit)
+		assert.Equal(t, paging.DefaultLimit, resp.Meta.Limit, limit)
+		assert.Equal(t, 2, resp.Meta.TotalPage, limit)
+	}
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/usecase"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+type reactionBody struct {
+	CandidateID uint64 `json:"candidateId" binding:"required"`
+}
+
+// likeData is the like result with why a mutual like did not become a match
+type likeData struct {
+	usecase.LikeResult
+	MatchError *matchError `json:",omitempty"`
+}
+
+type matchError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (rH *HttpHandler) likeHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	var body reactionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	result, err := rH.h.Like(ctx, uint64(id), body.CandidateID)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	data := likeData{LikeResult: result}
+	if result.MatchErr != nil {
+		code, msg, _ := cGin.ErrorMeta(result.MatchErr, http.StatusInternalServerError, "Internal Server Error")
+		data.MatchError = &matchError{Code: code, Message: msg}
+	}
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) passHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	var body reactionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	if err = rH.h.Pass(ctx, uint64(id), body.CandidateID); err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) blockHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	var body reactionBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	if err = rH.h.Block(ctx, uint64(id), body.CandidateID); err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.Response(http.StatusOK, "")
+}
+
+func (rH *HttpHandler) unblockHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	candidateIDStr := ctx.Param("candidateId")
+	candidateID, err := strconv.Atoi(candidateIDStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid candidate id")
+		return
+	}
+
+	if err = rH.h.Unblock(ctx, uint64(id), uint64(candidateID)); err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.Response(http.StatusOK, "")
+}
+
+type reportBody struct {
+	ReportedID uint64 `json:"reportedId" binding:"required"`
+	Reason     string `json:"reason"`
+}
+
+func (rH *HttpHandler) reportHandler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	var body reportBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	data, err := rH.h.Report(ctx, uint64(id), body.ReportedID, body.Reason)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(data).Response(http.StatusOK, "")
+}
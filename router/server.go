@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -10,20 +11,35 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
 	"github.com/ars0915/matching-system/config"
 	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/pb"
 	"github.com/ars0915/matching-system/util/cGin"
+	"github.com/ars0915/matching-system/util/cGrpc"
 )
 
 func (rH Handler) RunServer(ctx context.Context) (err error) {
+	// A server that fails stops the other one the same way a signal does
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	var (
 		httpSrv = &http.Server{
 			Addr:    ":" + config.Conf.Core.Port,
 			Handler: rH.http.routerEngine(),
 		}
-		errCh = make(chan error)
+		grpcSrv = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(cGrpc.UnaryServerInterceptor),
+			grpc.ChainStreamInterceptor(cGrpc.StopStreamsInterceptor(ctx), cGrpc.StreamServerInterceptor),
+		)
+		// Both servers may fail, neither sender should block
+		errCh = make(chan error, 2)
 	)
+	pb.RegisterMatchingServer(grpcSrv, rH.grpc)
+	// Shutdown waits for the event streams, which only end when the client leaves
+	httpSrv.RegisterOnShutdown(rH.http.stopStreams)
 
 	// http server
 	go func() {
@@ -35,9 +51,33 @@ func (rH Handler) RunServer(ctx context.Context) (err error) {
 		}
 	}()
 
-	shutdown := func(httpSrv *http.Server) {
+	// grpc server
+	go func() {
+		lis, err := net.Listen("tcp", ":"+config.Conf.Core.GRPCPort)
+		if err != nil {
+			errCh <- errors.Wrap(err, "listen grpc")
+			return
+		}
+
+		logrus.Info("gRPC server is running on " + config.Conf.Core.GRPCPort + " port.")
+		if err := grpcSrv.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+			errCh <- errors.Wrap(err, "serve grpc")
+		}
+	}()
+
+	// periodic snapshot
+	snapshotCtx, snapshotCancel := context.WithCancel(ctx)
+	defer snapshotCancel()
+	if interval := config.Conf.Snapshot.Interval; config.Conf.Snapshot.Path != "" && interval > 0 {
+		go rH.runSnapshotTicker(snapshotCtx, interval)
+	}
+
+	shutdown := func(httpSrv *http.Server, grpcSrv *grpc.Server) {
 		logrus.Warning("Gracefully Shutdown Server ...")
 
+		// Take the last snapshot after every request has finished
+		defer rH.saveSnapshot()
+
 		var (
 			finish      []interface{}
 			finishCount int
@@ -63,6 +103,13 @@ func (rH Handler) RunServer(ctx context.Context) (err error) {
 			}
 		}()
 
+		// Shutdown grpcSrv, the streams were ended by the cancelled ctx
+		finishCount++
+		go func() {
+			grpcSrv.GracefulStop()
+			finishCh <- struct{}{}
+		}()
+
 		for {
 			select {
 			case f := <-finishCh:
@@ -74,18 +121,53 @@ func (rH Handler) RunServer(ctx context.Context) (err error) {
 				}
 			case <-timeout.Done():
 				logrus.Error("Gracefully Shutdown Timeout.")
+				grpcSrv.Stop()
 				return
 			}
 		}
 	}
 
 	select {
-	case err := <-errCh:
-		return err
+	case err = <-errCh:
+		logrus.WithError(err).Error("Server failed")
+		cancel()
 	case <-ctx.Done():
-		shutdown(httpSrv)
-		return nil
 	}
+	shutdown(httpSrv, grpcSrv)
+
+	return err
+}
+
+func (rH Handler) runSnapshotTicker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rH.saveSnapshot()
+		}
+	}
+}
+
+func (rH Handler) saveSnapshot() {
+	if config.Conf.Snapshot.Path == "" {
+		return
+	}
+
+	info, err := rH.http.Usecase().SaveSnapshot(context.Background())
+	if err != nil {
+		logrus.WithError(err).Error("Save snapshot failed")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"path":        info.Path,
+		"personCount": info.PersonCount,
+		"matchCount":  info.MatchCount,
+	}).Info("Snapshot saved")
 }
 
 func (rH HttpHandler) routerEngine() *gin.Engine {
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/usecase"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+// defaultCandidateLimit is the page size of GET /v2/persons/:id/candidates without limit
+const defaultCandidateLimit = 10
+
+// personBody is the body of POST /v2/persons
+type personBody struct {
+	Name        string        `json:"name" binding:"required"`
+	Height      float64       `json:"height" binding:"required,gt=0"`
+	Gender      string        `json:"gender" binding:"required,oneof=male female non_binary"`
+	Seeking     []string      `json:"seeking" binding:"omitempty,dive,oneof=male female non_binary"`
+	WantedDates *uint64       `json:"wantedDates" binding:"required,gt=0"`
+	Age         int           `json:"age"`
+	City        string        `json:"city"`
+	Location    *locationBody `json:"location"`
+	Tags        []string      `json:"tags"`
+}
+
+func (b personBody) person() entity.Person {
+	seeking := make([]constant.Gender, len(b.Seeking))
+	for i, g := range b.Seeking {
+		seeking[i] = constant.Gender(g)
+	}
+
+	var location *entity.Location
+	if b.Location != nil {
+		location = &entity.Location{Latitude: b.Location.Latitude, Longitude: b.Location.Longitude}
+	}
+
+	return entity.Person{
+		Name:        b.Name,
+		Height:      b.Height,
+		Gender:      constant.Gender(b.Gender),
+		Seeking:     seeking,
+		WantedDates: b.WantedDates,
+		Age:         b.Age,
+		City:        b.City,
+		Location:    location,
+		Tags:        b.Tags,
+	}
+}
+
+// personV2 is entity.Person with the camelCase names of the v2 API
+type personV2 struct {
+	ID          uint64            `json:"id"`
+	Name        string            `json:"name"`
+	Height      float64           `json:"height"`
+	Gender      constant.Gender   `json:"gender"`
+	Seeking     []constant.Gender `json:"seeking"`
+	WantedDates uint64            `json:"wantedDates"`
+	Age         int               `json:"age"`
+	City        string            `json:"city"`
+	Location    *locationBody     `json:"location"`
+	Tags        []string          `json:"tags"`
+	Version     uint64            `json:"version"`
+}
+
+func newPersonV2(p entity.Person) personV2 {
+	v := personV2{
+		ID:      p.ID,
+		Name:    p.Name,
+		Height:  p.Height,
+		Gender:  p.Gender,
+		Seeking: p.Seeking,
+		Age:     p.Age,
+		City:    p.City,
+		Tags:    p.Tags,
+		Version: p.Version,
+	}
+	if p.WantedDates != nil {
+		v.WantedDates = *p.WantedDates
+	}
+	if p.Location != nil {
+		v.Location = &locationBody{Latitude: p.Location.Latitude, Longitude: p.Location.Longitude}
+	}
+	return v
+}
+
+type scoreFactorV2 struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Weight float64 `json:"weight"`
+}
+
+type candidateV2 struct {
+	personV2
+	Score     float64         `json:"score"`
+	Breakdown []scoreFactorV2 `json:"breakdown"`
+}
+
+func candidatesV2(candidates []usecase.Candidate) interface{} {
+	result := make([]candidateV2, len(candidates))
+	for i, c := range candidates {
+		result[i] = candidateV2{
+			personV2:  newPersonV2(c.Person),
+			Score:     c.Score,
+			Breakdown: make([]scoreFactorV2, len(c.Breakdown)),
+		}
+		for j, f := range c.Breakdown {
+			result[i].Breakdown[j] = scoreFactorV2(f)
+		}
+	}
+	return result
+}
+
+type matchV2Body struct {
+	PersonID1 uint64 `json:"personId1" binding:"required"`
+	PersonID2 uint64 `json:"personId2" binding:"required"`
+}
+
+type matchV2 struct {
+	ID        uint64               `json:"id"`
+	PersonID1 uint64               `json:"personId1"`
+	PersonID2 uint64               `json:"personId2"`
+	Status    constant.MatchStatus `json:"status"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+func newMatchV2(m entity.Match) interface{} {
+	return matchV2(m)
+}
+
+func (rH *HttpHandler) createPersonV2Handler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	var body personBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(cGin.BindingError(usecase.ErrorInvalidPerson, err)).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	data, err := rH.h.CreatePerson(ctx, body.person())
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	ctx.WithData(newPersonV2(data)).Response(http.StatusCreated, "")
+}
+
+func (rH *HttpHandler) getPersonV2Handler(c *gin.Context) {
+	rH.getPerson(cGin.NewContext(c), func(p entity.Person) interface{} {
+		return newPersonV2(p)
+	})
+}
+
+func (rH *HttpHandler) listCandidatesV2Handler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid id")
+		return
+	}
+
+	limit := defaultCandidateLimit
+	if s := ctx.Query("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil {
+			ctx.WithError(err).Response(http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	rH.candidates(ctx, uint64(id), limit, candidatesV2)
+}
+
+func (rH *HttpHandler) createMatchV2Handler(c *gin.Context) {
+	ctx := cGin.NewContext(c)
+
+	var body matchV2Body
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ctx.WithError(err).Response(http.StatusBadRequest, "Invalid Json")
+		return
+	}
+
+	rH.match(ctx, body.PersonID1, body.PersonID2, http.StatusCreated, newMatchV2)
+}
@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/internal/wal"
+)
+
+// maxBulkItems bounds a bulk request, which holds up every other mutation while it is applied
+const maxBulkItems = 5000
+
+// BulkResult is how one item of a bulk request went, ID is the person or match
+// ID when Err is nil
+type BulkResult struct {
+	ID  uint64
+	Err error
+}
+
+type PersonPair struct {
+	ID1 uint64
+	ID2 uint64
+}
+
+func (h *BulkHandler) AddPersons(ctx context.Context, persons []entity.Person) ([]BulkResult, error) {
+	if len(persons) > maxBulkItems {
+		return nil, ErrorBulkTooLarge
+	}
+	p := h.person
+
+	results := make([]BulkResult, len(persons))
+	var (
+		valid   []*entity.Person
+		indexes []int
+	)
+	for i := range persons {
+		person := persons[i]
+		withDefaultSeeking(&person)
+		normalizeAttributes(&person)
+		if err := validatePerson(person); err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, &person)
+		indexes = append(indexes, i)
+	}
+
+	p.gate.RLock()
+	defer p.gate.RUnlock()
+
+	records := make([]wal.Record, len(valid))
+	for i, person := range valid {
+		person.ID = p.GenerateNextID()
+		records[i] = wal.Record{Op: wal.OpAddPerson, Person: person}
+	}
+
+	err := p.loggedBatch(records, func(at time.Time) error {
+		for i, err := range p.addPersons(valid, at) {
+			results[indexes[i]] = BulkResult{ID: valid[i].ID, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// addPersons adds the persons with one BulkAdd per tree, errs[i] is the error of persons[i]
+func (h *PersonHandler) addPersons(persons []*entity.Person, at time.Time) []error {
+	byGender := map[constant.Gender][]int{}
+	for i, p := range persons {
+		byGender[p.Gender] = append(byGender[p.Gender], i)
+	}
+
+	errs := make([]error, len(persons))
+	for g, indexes := range byGender {
+		t, err := h.treeOf(g)
+		if err != nil {
+			for _, i := range indexes {
+				errs[i] = err
+			}
+			continue
+		}
+
+		batch := make([]*entity.Person, len(indexes))
+		for j, i := range indexes {
+			batch[j] = persons[i]
+		}
+		for j, err := range t.BulkAdd(batch) {
+			i := indexes[j]
+			switch {
+			case errors.Is(err, tree.ErrorPersonExist):
+				errs[i] = ErrorPersonExist
+			case err != nil:
+				errs[i] = err
+			default:
+				h.activity.touch(persons[i].ID, at)
+				h.notifyJoined(persons[i], at, nil)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (h *BulkHandler) RemovePersons(ctx context.Context, ids []uint64) ([]BulkResult, error) {
+	if len(ids) > maxBulkItems {
+		return nil, ErrorBulkTooLarge
+	}
+	p := h.person
+
+	p.gate.RLock()
+	defer p.gate.RUnlock()
+
+	records := make([]wal.Record, len(ids))
+	for i, id := range ids {
+		records[i] = wal.Record{Op: wal.OpRemovePerson, ID: id}
+	}
+
+	results := make([]BulkResult, len(ids))
+	err := p.loggedBatch(records, func(at time.Time) error {
+		for i, id := range ids {
+			results[i] = BulkResult{ID: id, Err: p.removePerson(id, at)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (h *BulkHandler) MatchPairs(ctx context.Context, pairs []PersonPair) ([]BulkResult, error) {
+	if len(pairs) > maxBulkItems {
+		return nil, ErrorBulkTooLarge
+	}
+	p := h.person
+
+	results := make([]BulkResult, len(pairs))
+	var (
+		valid   []PersonPair
+		indexes []int
+	)
+	for i, pair := range pairs {
+		if err := validatePair(pair); err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, pair)
+		indexes = append(indexes, i)
+	}
+
+	p.gate.RLock()
+	defer p.gate.RUnlock()
+
+	records := make([]wal.Record, len(valid))
+	for i, pair := range valid {
+		records[i] = wal.Record{Op: wal.OpMutualMatch, ID1: pair.ID1, ID2: pair.ID2}
+	}
+
+	err := p.loggedBatch(records, func(at time.Time) error {
+		for i, pair := range valid {
+			match, err := p.match(pair.ID1, pair.ID2, at, true)
+			results[indexes[i]] = BulkResult{ID: match.ID, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
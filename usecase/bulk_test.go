@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cGin"
+	"github.com/ars0915/matching-system/util/cTypes"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func Test_Bulk(t *testing.T) {
+	dir := t.TempDir()
+	h, l := recoverHandler(t, dir)
+	bulk := NewBulkHandler(h)
+	ctx := context.Background()
+
+	results, err := bulk.AddPersons(ctx, []entity.Person{
+		{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(2)},
+		{Name: "b", Height: 0, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)},
+		{Name: "c", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)},
+		{Name: "d", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, BulkResult{ID: 1}, results[0])
+	_, ok := results[1].Err.(cGin.ValidationError)
+	assert.True(t, ok, "the invalid person should fail alone")
+	assert.Equal(t, BulkResult{ID: 2}, results[2])
+	assert.Equal(t, BulkResult{ID: 3}, results[3])
+	assert.Equal(t, uint64(1), l.Seq(), "the batch should be logged once")
+
+	results, err = bulk.RemovePersons(ctx, []uint64{3, 99})
+	assert.Nil(t, err)
+	assert.Equal(t, []BulkResult{{ID: 3}, {ID: 99, Err: ErrorPersonNotFound}}, results)
+
+	_, err = bulk.RemovePersons(ctx, make([]uint64, maxBulkItems+1))
+	assert.Equal(t, ErrorBulkTooLarge, err)
+
+	// Replaying the batches gives the same pool
+	want, _, err := h.ListPersons(ctx, PersonQuery{}, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(t, err)
+	l.Close()
+	h, l = recoverHandler(t, dir)
+	defer l.Close()
+	got, _, err := h.ListPersons(ctx, PersonQuery{}, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(t, err)
+	assert.Equal(t, want, got)
+
+	// A mutual like is matched right away, so put the likes straight into the store
+	for _, like := range [][2]uint64{{1, 2}, {2, 1}} {
+		err := h.reactions.Put(entity.Reaction{PersonID: like[0], CandidateID: like[1], Kind: constant.ReactionLike, CreatedAt: time.Now()})
+		assert.Nil(t, err)
+	}
+	results, err = NewBulkHandler(h).MatchPairs(ctx, []PersonPair{{ID1: 1, ID2: 0}, {ID1: 1, ID2: 2}, {ID1: 1, ID2: 1}, {ID1: 1, ID2: 99}})
+	assert.Nil(t, err)
+	assert.Equal(t, cGin.ValidationError{
+		CustomError: ErrorInvalidPair,
+		Fields:      []cGin.FieldError{{Field: "id2", Message: "must be greater than 0"}},
+	}, results[0].Err, "an invalid pair should only fail its own item")
+	assert.Equal(t, BulkResult{ID: 1}, results[1], "the result should carry the match ID")
+	assert.Equal(t, ErrorMatchSamePerson, results[2].Err)
+	assert.Equal(t, ErrorPersonNotFound, results[3].Err)
+	_, err = h.GetPerson(ctx, 2)
+	assert.Equal(t, ErrorPersonNotFound, err, "the girl out of dates should be removed")
+}
+
+func Test_Bulk_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	h, l := recoverHandler(t, dir)
+	ctx := context.Background()
+
+	// The largest request the validation lets through is several times the wal record size
+	tags := make([]string, maxTags)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("%0*d", maxTagLength, i)
+	}
+	persons := make([]entity.Person, maxBulkItems)
+	for i := range persons {
+		persons[i] = entity.Person{
+			Name:        strings.Repeat("a", maxNameLength),
+			Height:      170,
+			Gender:      constant.GenderMale,
+			WantedDates: cTypes.Uint64(maxWantedDates),
+			Age:         maxAge,
+			City:        strings.Repeat("c", maxCityLength),
+			Tags:        tags,
+		}
+	}
+	results, err := NewBulkHandler(h).AddPersons(ctx, persons)
+	assert.Nil(t, err)
+	for _, r := range results {
+		assert.Nil(t, r.Err)
+	}
+	assert.Greater(t, l.Seq(), uint64(1), "the batch should be split over several records")
+
+	want, _, err := h.ListPersons(ctx, PersonQuery{}, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(t, err)
+	assert.Len(t, want, maxBulkItems)
+	l.Close()
+
+	h, l = recoverHandler(t, dir)
+	defer l.Close()
+	got, _, err := h.ListPersons(ctx, PersonQuery{}, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(t, err)
+	assert.Equal(t, want, got, "every person of the batch should be replayed")
+}
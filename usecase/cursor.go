@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ars0915/matching-system/internal/tree"
+)
+
+// encodeCursor hides the height and ID behind an opaque token, clients only pass it back
+func encodeCursor(c tree.Cursor) string {
+	raw := fmt.Sprintf("%s,%d", strconv.FormatFloat(c.Height, 'g', -1, 64), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor returns nil for an empty cursor, the query starts from the beginning
+func decodeCursor(s string) (*tree.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrorInvalidCursor
+	}
+	heightStr, idStr, found := strings.Cut(string(raw), ",")
+	if !found {
+		return nil, ErrorInvalidCursor
+	}
+
+	// ParseFloat takes NaN and Inf, which no tree can seek to
+	height, err := strconv.ParseFloat(heightStr, 64)
+	if err != nil || math.IsNaN(height) || math.IsInf(height, 0) {
+		return nil, ErrorInvalidCursor
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, ErrorInvalidCursor
+	}
+	return &tree.Cursor{Height: height, ID: id}, nil
+}
@@ -1,33 +1,185 @@
 package usecase
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/ars0915/matching-system/util/cGin"
 )
 
+// Error codes are part of the API, never reuse or change a published code
 var (
-	ErrorPersonNotFound = cGin.CustomError{
+	ErrorPersonNotFound = register(cGin.CustomError{
 		Code:     1001,
 		HTTPCode: http.StatusNotFound,
 		Message:  "Person not found",
-	}
+	})
 
-	ErrorMatchSameGender = cGin.CustomError{
-		Code:     1001,
-		HTTPCode: http.StatusBadRequest,
-		Message:  "Match same gender",
-	}
+	// 1002 "Match same gender" was retired when persons started declaring whom they seek
 
-	ErrorWantedDateLimit = cGin.CustomError{
-		Code:     1001,
+	ErrorWantedDateLimit = register(cGin.CustomError{
+		Code:     1003,
 		HTTPCode: http.StatusBadRequest,
 		Message:  "Wanted date limit",
-	}
+	})
 
-	ErrorHeightCheckFailed = cGin.CustomError{
-		Code:     1001,
+	ErrorHeightCheckFailed = register(cGin.CustomError{
+		Code:     1004,
 		HTTPCode: http.StatusBadRequest,
 		Message:  "Height check failed",
-	}
+	})
+
+	ErrorMatchNotFound = register(cGin.CustomError{
+		Code:     1005,
+		HTTPCode: http.StatusNotFound,
+		Message:  "Match not found",
+	})
+
+	ErrorSnapshotDisabled = register(cGin.CustomError{
+		Code:     1006,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Snapshot disabled",
+	})
+
+	ErrorInvalidPerson = register(cGin.CustomError{
+		Code:     1007,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Invalid person",
+	})
+
+	ErrorPersonExist = register(cGin.CustomError{
+		Code:     1008,
+		HTTPCode: http.StatusConflict,
+		Message:  "Person exist",
+	})
+
+	ErrorGenderIncompatible = register(cGin.CustomError{
+		Code:     1009,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Gender incompatible",
+	})
+
+	ErrorMatchSamePerson = register(cGin.CustomError{
+		Code:     1010,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Match same person",
+	})
+
+	ErrorNotMutualLike = register(cGin.CustomError{
+		Code:     1011,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Not liked by both",
+	})
+
+	ErrorInvalidSort = register(cGin.CustomError{
+		Code:     1012,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Invalid sort",
+	})
+
+	ErrorInvalidCursor = register(cGin.CustomError{
+		Code:     1013,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Invalid cursor",
+	})
+
+	ErrorInvalidFilter = register(cGin.CustomError{
+		Code:     1014,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Invalid filter",
+	})
+
+	ErrorLocationRequired = register(cGin.CustomError{
+		Code:     1015,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Location required",
+	})
+
+	ErrorBlocked = register(cGin.CustomError{
+		Code:     1016,
+		HTTPCode: http.StatusForbidden,
+		Message:  "Blocked",
+	})
+
+	ErrorInvalidReport = register(cGin.CustomError{
+		Code:     1017,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Invalid report",
+	})
+
+	ErrorReportNotFound = register(cGin.CustomError{
+		Code:     1018,
+		HTTPCode: http.StatusNotFound,
+		Message:  "Report not found",
+	})
+
+	ErrorReportResolved = register(cGin.CustomError{
+		Code:     1019,
+		HTTPCode: http.StatusConflict,
+		Message:  "Report resolved",
+	})
+
+	ErrorPersonSuspended = register(cGin.CustomError{
+		Code:     1020,
+		HTTPCode: http.StatusConflict,
+		Message:  "Person suspended",
+	})
+
+	ErrorPersonNotSuspended = register(cGin.CustomError{
+		Code:     1021,
+		HTTPCode: http.StatusNotFound,
+		Message:  "Person not suspended",
+	})
+
+	ErrorAlreadyMatched = register(cGin.CustomError{
+		Code:     1022,
+		HTTPCode: http.StatusConflict,
+		Message:  "Already matched",
+	})
+
+	ErrorVersionConflict = register(cGin.CustomError{
+		Code:     1023,
+		HTTPCode: http.StatusConflict,
+		Message:  "Version conflict",
+	})
+
+	ErrorBulkTooLarge = register(cGin.CustomError{
+		Code:     1024,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Too many items",
+	})
+
+	ErrorEventsDropped = register(cGin.CustomError{
+		Code:     1025,
+		HTTPCode: http.StatusServiceUnavailable,
+		Message:  "Fell behind the events",
+	})
+
+	ErrorInvalidPair = register(cGin.CustomError{
+		Code:     1026,
+		HTTPCode: http.StatusBadRequest,
+		Message:  "Invalid pair",
+	})
 )
+
+var catalogue = map[int]cGin.CustomError{}
+
+// register adds cErr to the error catalogue and panics on a duplicated code
+func register(cErr cGin.CustomError) cGin.CustomError {
+	if exist, ok := catalogue[cErr.Code]; ok {
+		panic(fmt.Sprintf("error code %d of %q is used by %q", cErr.Code, cErr.Message, exist.Message))
+	}
+	catalogue[cErr.Code] = cErr
+	return cErr
+}
+
+// ErrorCatalogue lists every registered error ordered by code
+func ErrorCatalogue() []cGin.CustomError {
+	result := make([]cGin.CustomError, 0, len(catalogue))
+	for _, cErr := range catalogue {
+		result = append(result, cErr)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
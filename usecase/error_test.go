@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_ErrorCatalogue(t *testing.T) {
+	got := ErrorCatalogue()
+
+	codes := map[int]bool{}
+	for i, cErr := range got {
+		assert.False(t, codes[cErr.Code], "code %d should be unique", cErr.Code)
+		codes[cErr.Code] = true
+		if i > 0 {
+			assert.Less(t, got[i-1].Code, cErr.Code, "catalogue should be ordered by code")
+		}
+	}
+	assert.Contains(t, got, ErrorPersonNotFound)
+	assert.Contains(t, got, ErrorPersonExist)
+}
+
+func Test_registerDuplicatedCode(t *testing.T) {
+	assert.Panics(t, func() { register(ErrorPersonNotFound) })
+}
+
+func (s *personTestSuite) Test_AddPersonExist() {
+	s.boys.EXPECT().AddPerson(gomock.Any()).Return(tree.ErrorPersonExist)
+
+	_, err := s.h.AddPerson(entity.Person{
+		Name:        "a",
+		Height:      170,
+		Gender:      "male",
+		WantedDates: cTypes.Uint64(1),
+	})
+	assert.Equal(s.T(), ErrorPersonExist, err)
+}
@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+const (
+	// eventBuffer is how many events a subscriber may fall behind before it is dropped
+	eventBuffer = 64
+	// watchPageSize is the page size WatchCandidates sends the current candidates with
+	watchPageSize = 50
+)
+
+// Event is something that happened to the person PersonID
+type Event struct {
+	Type     constant.EventType
+	PersonID uint64
+	// Candidate is the person who joined, set for EventCandidateJoined
+	Candidate *entity.Person
+	// Match is set for EventMatched
+	Match *entity.Match
+	At    time.Time
+}
+
+func (h *EventHandler) SubscribeEvents(ctx context.Context, id uint64) (<-chan Event, error) {
+	p := h.person
+
+	sub, err := p.subscribe(id)
+	if err != nil {
+		return nil, err
+	}
+	context.AfterFunc(ctx, func() {
+		p.events.unsubscribe(id, sub)
+	})
+
+	return sub, nil
+}
+
+// WatchCandidates returns ErrorPersonNotFound once the person is removed and
+// ErrorEventsDropped when send is too slow to keep up with the persons who join
+func (h *EventHandler) WatchCandidates(ctx context.Context, id uint64, filter CandidateFilter, send func(Candidate) error) error {
+	p := h.person
+
+	// Subscribe before the first page, so no one who joins in between is missed
+	sub, err := p.subscribe(id)
+	if err != nil {
+		return err
+	}
+	defer p.events.unsubscribe(id, sub)
+
+	// sent holds the candidates of the pages, only to skip them when their joins were
+	// published while the pages were sent
+	sent := map[uint64]bool{}
+	q := CandidateQuery{Num: watchPageSize, Filter: filter}
+	for {
+		page, err := p.QuerySinglePeople(ctx, id, q)
+		if err != nil {
+			return err
+		}
+		for _, c := range page.Candidates {
+			if err := send(c); err != nil {
+				return err
+			}
+			sent[c.ID] = true
+		}
+		if page.Next == "" {
+			break
+		}
+		q.Cursor = page.Next
+	}
+	pending := len(sub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, open := <-sub:
+			if !open {
+				if _, err := p.findPerson(id); err != nil {
+					return err
+				}
+				return ErrorEventsDropped
+			}
+			// Only the events buffered while the pages were sent can repeat a sent candidate
+			if pending == 0 {
+				sent = nil
+			} else {
+				pending--
+			}
+			if ev.Type != constant.EventCandidateJoined || sent[ev.Candidate.ID] {
+				continue
+			}
+
+			c, ok, err := p.watchedCandidate(id, *ev.Candidate, filter)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if err := send(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscribe subscribes to the events of person id, indexed by the genders the person seeks
+func (h *PersonHandler) subscribe(id uint64) (chan Event, error) {
+	// An update of the person cannot change the genders between the lookup and the index
+	unlock := h.lockPair(id, id)
+	defer unlock()
+
+	// Subscribe before looking the person up, so a removal in between still closes the channel
+	sub := h.events.subscribe(id)
+	person, err := h.findPerson(id)
+	if err != nil {
+		h.events.unsubscribe(id, sub)
+		return nil, err
+	}
+	h.events.index(id, person.Seeking)
+
+	return sub, nil
+}
+
+// watchedCandidate scores candidate for person id, ok is false when the candidate does not pass
+// filter or is hidden from the person
+func (h *PersonHandler) watchedCandidate(id uint64, candidate entity.Person, filter CandidateFilter) (_ Candidate, ok bool, err error) {
+	person, err := h.findPerson(id)
+	if err != nil {
+		return Candidate{}, false, err
+	}
+	f, err := treeFilter(*person, filter)
+	if err != nil {
+		return Candidate{}, false, err
+	}
+	if !h.isCandidate(*person, candidate) || !f.Match(candidate) {
+		return Candidate{}, false, nil
+	}
+	hidden, err := h.hiddenSet(person.ID)
+	if err != nil {
+		return Candidate{}, false, err
+	}
+	if hidden[candidate.ID] {
+		return Candidate{}, false, nil
+	}
+	return h.scoreCandidates(*person, []entity.Person{candidate})[0], true, nil
+}
+
+// notifyJoined tells the subscribers who would see p among their candidates that p joined.
+// When p was updated from before, those who saw it before already are not told again.
+func (h *PersonHandler) notifyJoined(p *entity.Person, at time.Time, before *entity.Person) {
+	for _, id := range h.events.subscribers(p.Gender) {
+		person, err := h.findPerson(id)
+		if err != nil || !h.isCandidate(*person, *p) || (before != nil && h.isCandidate(*person, *before)) {
+			continue
+		}
+		candidate := copyPerson(*p)
+		h.events.publish(id, Event{Type: constant.EventCandidateJoined, PersonID: id, Candidate: &candidate, At: at})
+	}
+}
+
+// isCandidate reports whether candidate fits the genders and the height range of the
+// candidates of person, the persons hidden from person are not checked
+func (h *PersonHandler) isCandidate(person, candidate entity.Person) bool {
+	if person.ID == candidate.ID || !person.CompatibleWith(candidate) {
+		return false
+	}
+	minHeight, maxHeight := h.policy.CandidateRange(person, candidate.Gender)
+	return candidate.Height >= minHeight && candidate.Height <= maxHeight
+}
+
+func (h *PersonHandler) notifyMatched(m entity.Match) {
+	for _, id := range []uint64{m.PersonID1, m.PersonID2} {
+		match := m
+		h.events.publish(id, Event{Type: constant.EventMatched, PersonID: id, Match: &match, At: m.CreatedAt})
+	}
+}
+
+// notifyRemoved sends the last event of each person and closes their subscriptions
+func (h *PersonHandler) notifyRemoved(at time.Time, ids ...uint64) {
+	for _, id := range ids {
+		h.events.publish(id, Event{Type: constant.EventRemoved, PersonID: id, At: at})
+		h.events.close(id)
+	}
+}
+
+// eventHub holds the subscriptions by person ID, a subscription is a buffered channel
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[uint64]map[chan Event]struct{}
+	// seekers indexes the subscribed persons by the genders they seek, so a person who
+	// joins is only checked against the subscribers who may see them
+	seekers map[constant.Gender]map[uint64]struct{}
+	seeking map[uint64][]constant.Gender
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subs:    map[uint64]map[chan Event]struct{}{},
+		seekers: map[constant.Gender]map[uint64]struct{}{},
+		seeking: map[uint64][]constant.Gender{},
+	}
+}
+
+func (e *eventHub) subscribe(id uint64) chan Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ch := make(chan Event, eventBuffer)
+	if e.subs[id] == nil {
+		e.subs[id] = map[chan Event]struct{}{}
+	}
+	e.subs[id][ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe closes ch unless it was closed already
+func (e *eventHub) unsubscribe(id uint64, ch chan Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.drop(id, ch)
+}
+
+func (e *eventHub) drop(id uint64, ch chan Event) {
+	if _, exist := e.subs[id][ch]; !exist {
+		return
+	}
+	close(ch)
+	delete(e.subs[id], ch)
+	if len(e.subs[id]) == 0 {
+		delete(e.subs, id)
+		e.unindex(id)
+	}
+}
+
+// index files the subscribed person id under the genders it seeks, replacing the
+// previous ones. It does nothing when the person has no subscription.
+func (e *eventHub) index(id uint64, seeking []constant.Gender) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exist := e.subs[id]; !exist {
+		return
+	}
+	e.unindex(id)
+	for _, g := range seeking {
+		if e.seekers[g] == nil {
+			e.seekers[g] = map[uint64]struct{}{}
+		}
+		e.seekers[g][id] = struct{}{}
+	}
+	e.seeking[id] = seeking
+}
+
+func (e *eventHub) unindex(id uint64) {
+	for _, g := range e.seeking[id] {
+		delete(e.seekers[g], id)
+		if len(e.seekers[g]) == 0 {
+			delete(e.seekers, g)
+		}
+	}
+	delete(e.seeking, id)
+}
+
+// subscribers returns the subscribed persons who seek gender g
+func (e *eventHub) subscribers(g constant.Gender) []uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ids := make([]uint64, 0, len(e.seekers[g]))
+	for id := range e.seekers[g] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// publish never blocks, a subscriber whose buffer is full is dropped rather than
+// silently missing the event
+func (e *eventHub) publish(id uint64, ev Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.subs[id] {
+		select {
+		case ch <- ev:
+		default:
+			e.drop(id, ch)
+		}
+	}
+}
+
+func (e *eventHub) close(id uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.subs[id] {
+		e.drop(id, ch)
+	}
+}
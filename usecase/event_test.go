@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_SubscribeEvents(t *testing.T) {
+	h := newTestHandler(t)
+	events := NewEventHandler(h)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := events.SubscribeEvents(ctx, 99)
+	assert.Equal(t, ErrorPersonNotFound, err)
+
+	boy, err := h.AddPerson(entity.Person{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	ch, err := events.SubscribeEvents(ctx, boy.ID)
+	assert.Nil(t, err)
+
+	// Only the persons the boy would see among his candidates are told
+	for _, p := range []entity.Person{
+		{Name: "b", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+		{Name: "c", Height: 185, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)},
+		{Name: "d", Height: 160, Gender: constant.GenderFemale, Seeking: []constant.Gender{constant.GenderFemale}, WantedDates: cTypes.Uint64(1)},
+		{Name: "e", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(2)},
+	} {
+		_, err := h.AddPerson(p)
+		assert.Nil(t, err)
+	}
+	ev := <-ch
+	assert.Equal(t, constant.EventCandidateJoined, ev.Type)
+	assert.Equal(t, "e", ev.Candidate.Name)
+
+	_, err = h.Like(ctx, boy.ID, ev.Candidate.ID)
+	assert.Nil(t, err)
+	result, err := h.Like(ctx, ev.Candidate.ID, boy.ID)
+	assert.Nil(t, err)
+	assert.True(t, result.Matched)
+
+	// The match comes before the removal it caused, which ends the subscription
+	ev = <-ch
+	assert.Equal(t, constant.EventMatched, ev.Type)
+	assert.Equal(t, result.Match.ID, ev.Match.ID)
+	ev = <-ch
+	assert.Equal(t, Event{Type: constant.EventRemoved, PersonID: boy.ID, At: ev.At}, ev)
+	_, open := <-ch
+	assert.False(t, open, "the channel should be closed after the removal")
+
+	// Cancelling the context closes the channel too
+	girl, err := h.AddPerson(entity.Person{Name: "f", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	ch, err = events.SubscribeEvents(ctx, girl.ID)
+	assert.Nil(t, err)
+	cancel()
+	_, open = <-ch
+	assert.False(t, open, "the channel should be closed with the context")
+}
+
+func Test_WatchCandidates(t *testing.T) {
+	h := newTestHandler(t)
+	events := NewEventHandler(h)
+	ctx := context.Background()
+
+	add := func(p entity.Person) entity.Person {
+		p, err := h.AddPerson(p)
+		assert.Nil(t, err)
+		return p
+	}
+	boy := add(entity.Person{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)})
+	add(entity.Person{Name: "b", Height: 160, Gender: constant.GenderFemale, City: "Taipei", WantedDates: cTypes.Uint64(1)})
+	add(entity.Person{Name: "c", Height: 160, Gender: constant.GenderFemale, City: "Tainan", WantedDates: cTypes.Uint64(1)})
+
+	sent := make(chan Candidate)
+	done := make(chan error, 1)
+	go func() {
+		done <- events.WatchCandidates(ctx, boy.ID, CandidateFilter{City: "Taipei"}, func(c Candidate) error {
+			sent <- c
+			return nil
+		})
+	}()
+
+	c := <-sent
+	assert.Equal(t, "b", c.Name, "the current candidates should be filtered")
+
+	// The filter applies to the persons who join too
+	add(entity.Person{Name: "d", Height: 165, Gender: constant.GenderFemale, City: "Tainan", WantedDates: cTypes.Uint64(1)})
+	add(entity.Person{Name: "e", Height: 165, Gender: constant.GenderFemale, City: "Taipei", WantedDates: cTypes.Uint64(1)})
+	c = <-sent
+	assert.Equal(t, "e", c.Name)
+	assert.NotZero(t, c.Score)
+
+	// Removing the person ends the watch
+	assert.Nil(t, h.RemovePerson(ctx, boy.ID))
+	assert.Equal(t, ErrorPersonNotFound, <-done)
+
+	err := events.WatchCandidates(ctx, boy.ID, CandidateFilter{}, func(Candidate) error { return nil })
+	assert.Equal(t, ErrorPersonNotFound, err)
+}
+
+func Test_SubscribeEventsUpdateAndUnsuspend(t *testing.T) {
+	h := newTestHandler(t)
+	events := NewEventHandler(h)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	boy, err := h.AddPerson(entity.Person{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	girl, err := h.AddPerson(entity.Person{Name: "b", Height: 185, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	ch, err := events.SubscribeEvents(ctx, boy.ID)
+	assert.Nil(t, err)
+
+	// An update that brings the girl into range makes her join, a later one does not again
+	height, name := 160.0, "c"
+	_, err = h.UpdatePerson(ctx, girl.ID, entity.PersonPatch{Height: &height, Version: 0})
+	assert.Nil(t, err)
+	_, err = h.UpdatePerson(ctx, girl.ID, entity.PersonPatch{Name: &name, Version: 1})
+	assert.Nil(t, err)
+
+	// So does lifting a suspension
+	moderation := NewModerationHandler(h)
+	assert.Nil(t, moderation.SuspendPerson(ctx, girl.ID))
+	assert.Nil(t, moderation.UnsuspendPerson(ctx, girl.ID))
+
+	for _, want := range []string{"b", "c"} {
+		ev := <-ch
+		assert.Equal(t, constant.EventCandidateJoined, ev.Type)
+		assert.Equal(t, want, ev.Candidate.Name)
+		assert.Equal(t, 160.0, ev.Candidate.Height)
+	}
+	assert.Empty(t, ch, "the rename should not tell the boy again")
+}
+
+func Test_SubscribeEventsSuspend(t *testing.T) {
+	h := newTestHandler(t)
+	events := NewEventHandler(h)
+	moderation := NewModerationHandler(h)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	boy, err := h.AddPerson(entity.Person{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	ch, err := events.SubscribeEvents(ctx, boy.ID)
+	assert.Nil(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- events.WatchCandidates(ctx, boy.ID, CandidateFilter{}, func(Candidate) error { return nil })
+	}()
+
+	// Suspending the person ends the subscription like a removal
+	assert.Nil(t, moderation.SuspendPerson(ctx, boy.ID))
+	ev := <-ch
+	assert.Equal(t, Event{Type: constant.EventRemoved, PersonID: boy.ID, At: ev.At}, ev)
+	_, open := <-ch
+	assert.False(t, open, "the channel should be closed after the suspension")
+	assert.Equal(t, ErrorPersonNotFound, <-done)
+
+	_, err = events.SubscribeEvents(ctx, boy.ID)
+	assert.Equal(t, ErrorPersonNotFound, err, "a suspended person cannot subscribe")
+}
+
+func Test_eventHubIndex(t *testing.T) {
+	e := newEventHub()
+	sub := e.subscribe(1)
+	e.index(1, []constant.Gender{constant.GenderFemale})
+	e.index(2, []constant.Gender{constant.GenderFemale})
+	assert.Equal(t, []uint64{1}, e.subscribers(constant.GenderFemale), "only subscribed persons are indexed")
+	assert.Empty(t, e.subscribers(constant.GenderMale))
+
+	// A new seeking replaces the old one
+	e.index(1, []constant.Gender{constant.GenderMale})
+	assert.Empty(t, e.subscribers(constant.GenderFemale))
+	assert.Equal(t, []uint64{1}, e.subscribers(constant.GenderMale))
+
+	e.unsubscribe(1, sub)
+	assert.Empty(t, e.subscribers(constant.GenderMale), "the last unsubscribe should leave the index")
+}
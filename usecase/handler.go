@@ -1,11 +1,31 @@
 package usecase
 
 import (
+	"sync"
+	"time"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
 	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/internal/wal"
+)
+
+const (
+	matchLockStripes = 64
+	// defaultLikeTTL is how long a like waits for the candidate to like back
+	defaultLikeTTL = 72 * time.Hour
 )
 
 type AppHandler struct {
 	Person
+	MatchHistory
+	Snapshot
+	Round
+	Moderation
+	Bulk
+	Events
 }
 
 type NewHandlerOption func(*AppHandler)
@@ -21,16 +41,113 @@ func newHandler(optFn ...NewHandlerOption) *AppHandler {
 }
 
 type PersonHandler struct {
-	boys  tree.Tree
-	girls tree.Tree
-	id    *uint64
+	// trees holds one tree per gender
+	trees     map[constant.Gender]tree.Tree
+	matches   matchstore.Store
+	reactions reactionstore.Store
+	reports   reportstore.Store
+	policy    MatchPolicy
+	likeTTL   time.Duration
+	// allowRematch lets a pair match again and keeps former partners among the candidates
+	allowRematch bool
+	scoring      Scoring
+	activity     *activityLog
+	events       *eventHub
+	id           *uint64
+
+	matchLocks [matchLockStripes]sync.Mutex
+	// gate is shared by mutations and held exclusively while a snapshot is taken
+	gate sync.RWMutex
+	// moving is held by a gender change while the person moves between trees,
+	// and shared by the reads that look across the trees
+	moving sync.RWMutex
+
+	wal   *wal.Log
+	walMu sync.Mutex
+}
+
+type PersonHandlerOption func(*PersonHandler)
+
+func NewPersonHandler(
+	trees map[constant.Gender]tree.Tree,
+	matchStore matchstore.Store,
+	reactionStore reactionstore.Store,
+	reportStore reportstore.Store,
+	optFn ...PersonHandlerOption,
+) *PersonHandler {
+	h := &PersonHandler{
+		trees:     trees,
+		matches:   matchStore,
+		reactions: reactionStore,
+		reports:   reportStore,
+		policy:    TallerMalePolicy{},
+		likeTTL:   defaultLikeTTL,
+		activity:  newActivityLog(),
+		events:    newEventHub(),
+		id:        new(uint64),
+	}
+	// Every registered scorer weighted the same
+	h.scoring, _ = NewScoring(nil)
+
+	for _, o := range optFn {
+		o(h)
+	}
+
+	return h
+}
+
+// WithLastPersonID continues ID generation after id, used when persons are restored from storage
+func WithLastPersonID(id uint64) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		if *h.id < id {
+			*h.id = id
+		}
+	}
+}
+
+// WithActivity restores when each person was last active, as saved in a snapshot
+func WithActivity(activity map[uint64]time.Time) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		for id, at := range activity {
+			h.activity.touch(id, at)
+		}
+	}
+}
+
+// WithMatchPolicy replaces the default TallerMalePolicy
+func WithMatchPolicy(policy MatchPolicy) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.policy = policy
+	}
+}
+
+// WithLikeTTL replaces how long a like waits for the candidate to like back
+func WithLikeTTL(ttl time.Duration) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.likeTTL = ttl
+	}
+}
+
+// WithRematch lets persons who were matched before match again
+func WithRematch(allow bool) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.allowRematch = allow
+	}
+}
+
+// WithScoring replaces how candidates are scored
+func WithScoring(s Scoring) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.scoring = s
+	}
 }
 
-func NewPersonHandler(boysTree, girlsTree tree.Tree) *PersonHandler {
-	return &PersonHandler{
-		boys:  boysTree,
-		girls: girlsTree,
-		id:    new(uint64),
+// WithWAL logs every mutation to l before applying it, after replaying the
+// records that are not covered by the restored snapshot yet
+func WithWAL(l *wal.Log, pending []wal.Record) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.replay(pending)
+		h.wal = l
 	}
 }
 
@@ -39,3 +156,104 @@ func WithPerson(i *PersonHandler) func(h *AppHandler) {
 		h.Person = i
 	}
 }
+
+type MatchHandler struct {
+	matches matchstore.Store
+}
+
+func NewMatchHandler(matchStore matchstore.Store) *MatchHandler {
+	return &MatchHandler{
+		matches: matchStore,
+	}
+}
+
+func WithMatchHistory(i *MatchHandler) func(h *AppHandler) {
+	return func(h *AppHandler) {
+		h.MatchHistory = i
+	}
+}
+
+type SnapshotHandler struct {
+	person *PersonHandler
+	path   string
+	mu     sync.Mutex
+}
+
+func NewSnapshotHandler(person *PersonHandler, path string) *SnapshotHandler {
+	return &SnapshotHandler{
+		person: person,
+		path:   path,
+	}
+}
+
+func WithSnapshot(i *SnapshotHandler) func(h *AppHandler) {
+	return func(h *AppHandler) {
+		h.Snapshot = i
+	}
+}
+
+type RoundHandler struct {
+	person *PersonHandler
+	score  ScoreFunc
+}
+
+func NewRoundHandler(person *PersonHandler) *RoundHandler {
+	return &RoundHandler{
+		person: person,
+		score:  HeightScore,
+	}
+}
+
+func WithRound(i *RoundHandler) func(h *AppHandler) {
+	return func(h *AppHandler) {
+		h.Round = i
+	}
+}
+
+type ModerationHandler struct {
+	person *PersonHandler
+}
+
+func NewModerationHandler(person *PersonHandler) *ModerationHandler {
+	return &ModerationHandler{
+		person: person,
+	}
+}
+
+func WithModeration(i *ModerationHandler) func(h *AppHandler) {
+	return func(h *AppHandler) {
+		h.Moderation = i
+	}
+}
+
+type BulkHandler struct {
+	person *PersonHandler
+}
+
+func NewBulkHandler(person *PersonHandler) *BulkHandler {
+	return &BulkHandler{
+		person: person,
+	}
+}
+
+func WithBulk(i *BulkHandler) func(h *AppHandler) {
+	return func(h *AppHandler) {
+		h.Bulk = i
+	}
+}
+
+type EventHandler struct {
+	person *PersonHandler
+}
+
+func NewEventHandler(person *PersonHandler) *EventHandler {
+	return &EventHandler{
+		person: person,
+	}
+}
+
+func WithEvents(i *EventHandler) func(h *AppHandler) {
+	return func(h *AppHandler) {
+		h.Events = i
+	}
+}
@@ -1,13 +1,36 @@
 package usecase
 
 import (
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
 	"github.com/ars0915/matching-system/internal/tree"
 )
 
-func InitHandler(boysTree, girlsTree tree.Tree) Handler {
-	person := NewPersonHandler(boysTree, girlsTree)
+func InitHandler(
+	trees map[constant.Gender]tree.Tree,
+	matchStore matchstore.Store,
+	reactionStore reactionstore.Store,
+	reportStore reportstore.Store,
+	snapshotPath string,
+	personOpts ...PersonHandlerOption,
+) Handler {
+	person := NewPersonHandler(trees, matchStore, reactionStore, reportStore, personOpts...)
+	match := NewMatchHandler(matchStore)
+	snap := NewSnapshotHandler(person, snapshotPath)
+	round := NewRoundHandler(person)
+	moderation := NewModerationHandler(person)
+	bulk := NewBulkHandler(person)
+	events := NewEventHandler(person)
 	h := newHandler(
 		WithPerson(person),
+		WithMatchHistory(match),
+		WithSnapshot(snap),
+		WithRound(round),
+		WithModeration(moderation),
+		WithBulk(bulk),
+		WithEvents(events),
 	)
 
 	return h
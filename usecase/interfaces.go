@@ -4,20 +4,101 @@ package usecase
 import (
 	"context"
 
+	"github.com/ars0915/matching-system/constant"
 	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/snapshot"
+	"github.com/ars0915/matching-system/util/paging"
 )
 
 type (
 	Handler interface {
 		Person
+		MatchHistory
+		Snapshot
+		Round
+		Moderation
+		Bulk
+		Events
 	}
 )
 
 type (
 	Person interface {
 		AddPersonAndFindMatch(ctx context.Context, p entity.Person) ([]entity.Person, error)
+		// CreatePerson adds p without looking for matches
+		CreatePerson(ctx context.Context, p entity.Person) (entity.Person, error)
+		GetPerson(ctx context.Context, id uint64) (entity.Person, error)
+		// ListPersons returns a page of the persons q selects ordered by height and ID, and the number of them
+		ListPersons(ctx context.Context, q PersonQuery, page paging.Paginator) ([]entity.Person, int, error)
 		RemovePerson(ctx context.Context, id uint64) error
-		QuerySinglePeople(ctx context.Context, id uint64, num int) ([]entity.Person, error)
-		Match(ctx context.Context, id1, id2 uint64) error
+		// UpdatePerson changes the profile of id when patch names its current version
+		UpdatePerson(ctx context.Context, id uint64, patch entity.PersonPatch) (entity.Person, error)
+		// QuerySinglePeople returns a page of the candidates of id
+		QuerySinglePeople(ctx context.Context, id uint64, q CandidateQuery) (CandidatePage, error)
+		// Match pairs two people who have liked each other
+		Match(ctx context.Context, id1, id2 uint64) (entity.Match, error)
+		// Like records that id likes candidateID and matches them when the like is mutual
+		Like(ctx context.Context, id, candidateID uint64) (LikeResult, error)
+		// Pass hides candidateID from the later queries of id
+		Pass(ctx context.Context, id, candidateID uint64) error
+		// Block hides id and candidateID from each other and keeps them from matching
+		Block(ctx context.Context, id, candidateID uint64) error
+		// Unblock lifts the block of id on candidateID
+		Unblock(ctx context.Context, id, candidateID uint64) error
+		// Report puts a complaint of id about reportedID in the moderation queue
+		Report(ctx context.Context, id, reportedID uint64, reason string) (entity.Report, error)
+	}
+
+	MatchHistory interface {
+		ListMatches(ctx context.Context, page paging.Paginator) ([]entity.Match, int, error)
+		ListPersonMatches(ctx context.Context, personID uint64, page paging.Paginator) ([]entity.Match, int, error)
+		GetMatch(ctx context.Context, id uint64) (entity.Match, error)
+	}
+
+	Snapshot interface {
+		SaveSnapshot(ctx context.Context) (snapshot.Info, error)
+	}
+
+	Round interface {
+		// RunRound pairs the whole pool at once, the pairs only become matches when commit is true
+		RunRound(ctx context.Context, commit bool) (RoundReport, error)
+	}
+
+	Moderation interface {
+		// ListReports lists the reports of the status, every report when status is empty
+		ListReports(ctx context.Context, status constant.ReportStatus, page paging.Paginator) ([]entity.Report, int, error)
+		DismissReport(ctx context.Context, id uint64) (entity.Report, error)
+		// SuspendPerson hides the person from every tree and resolves the open reports on them
+		SuspendPerson(ctx context.Context, id uint64) error
+		UnsuspendPerson(ctx context.Context, id uint64) error
+	}
+
+	// Bulk applies many items in one request, results[i] tells how item i went and the
+	// error is only returned when the request as a whole failed
+	Bulk interface {
+		// AddPersons adds the persons with one lock per tree, without looking for matches
+		AddPersons(ctx context.Context, persons []entity.Person) ([]BulkResult, error)
+		RemovePersons(ctx context.Context, ids []uint64) ([]BulkResult, error)
+		// MatchPairs matches each pair who have liked each other
+		MatchPairs(ctx context.Context, pairs []PersonPair) ([]BulkResult, error)
+	}
+
+	Events interface {
+		// SubscribeEvents sends the events of id until ctx is done, the person is removed or
+		// the subscriber falls behind, then closes the channel
+		SubscribeEvents(ctx context.Context, id uint64) (<-chan Event, error)
+		// WatchCandidates calls send with the candidates of id that pass filter, first the
+		// current ones and then each one who joins, until ctx is done or an error ends it
+		WatchCandidates(ctx context.Context, id uint64, filter CandidateFilter, send func(Candidate) error) error
+	}
+)
+
+type (
+	// MatchPolicy decides which candidates a person can see and which pairs can match
+	MatchPolicy interface {
+		// CandidateRange returns the height range of the candidates of candidateGender shown to p
+		CandidateRange(p entity.Person, candidateGender constant.Gender) (minHeight, maxHeight float64)
+		// Check returns the CustomError explaining why p1 and p2 cannot match, nil if they can
+		Check(p1, p2 entity.Person) error
 	}
 )
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func (h *MatchHandler) ListMatches(ctx context.Context, page paging.Paginator) ([]entity.Match, int, error) {
+	matches, total, err := h.matches.List(page.Offset, page.Limit)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "list matches")
+	}
+	return matches, total, nil
+}
+
+func (h *MatchHandler) ListPersonMatches(ctx context.Context, personID uint64, page paging.Paginator) ([]entity.Match, int, error) {
+	matches, total, err := h.matches.ListByPerson(personID, page.Offset, page.Limit)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "list person matches")
+	}
+	return matches, total, nil
+}
+
+func (h *MatchHandler) GetMatch(ctx context.Context, id uint64) (entity.Match, error) {
+	m, exist, err := h.matches.FindByID(id)
+	if err != nil {
+		return entity.Match{}, errors.Wrap(err, "find match")
+	}
+	if !exist {
+		return entity.Match{}, ErrorMatchNotFound
+	}
+	return *m, nil
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/internal/wal"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func (h *PersonHandler) Report(ctx context.Context, id, reportedID uint64, reason string) (entity.Report, error) {
+	reason = strings.TrimSpace(reason)
+	if err := validateReason(reason); err != nil {
+		return entity.Report{}, err
+	}
+
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	report := entity.Report{
+		ReporterID: id,
+		ReportedID: reportedID,
+		Reason:     reason,
+		Status:     constant.ReportOpen,
+	}
+	err := h.logged(wal.Record{Op: wal.OpReport, Report: &report}, func(at time.Time) error {
+		return h.report(&report, at)
+	})
+
+	return report, err
+}
+
+func (h *PersonHandler) report(r *entity.Report, at time.Time) error {
+	if _, _, err := h.findReactionPair(r.ReporterID, r.ReportedID); err != nil {
+		return err
+	}
+
+	r.CreatedAt = at
+	if err := h.reports.AddReport(r); err != nil {
+		return errors.Wrap(err, "add report")
+	}
+	return nil
+}
+
+func (h *ModerationHandler) ListReports(ctx context.Context, status constant.ReportStatus, page paging.Paginator) ([]entity.Report, int, error) {
+	if status != "" && !validReportStatus(status) {
+		return nil, 0, ErrorInvalidReport
+	}
+
+	reports, total, err := h.person.reports.List(status, page.Offset, page.Limit)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "list reports")
+	}
+	return reports, total, nil
+}
+
+func validReportStatus(status constant.ReportStatus) bool {
+	switch status {
+	case constant.ReportOpen, constant.ReportDismissed, constant.ReportActioned:
+		return true
+	}
+	return false
+}
+
+func (h *ModerationHandler) DismissReport(ctx context.Context, id uint64) (entity.Report, error) {
+	p := h.person
+
+	p.gate.RLock()
+	defer p.gate.RUnlock()
+
+	var report entity.Report
+	record := wal.Record{Op: wal.OpResolveReport, Report: &entity.Report{ID: id, Status: constant.ReportDismissed}}
+	err := p.logged(record, func(at time.Time) (err error) {
+		report, err = p.resolveReport(id, constant.ReportDismissed, at)
+		return err
+	})
+
+	return report, err
+}
+
+func (h *PersonHandler) resolveReport(id uint64, status constant.ReportStatus, at time.Time) (entity.Report, error) {
+	r, exist, err := h.reports.FindByID(id)
+	if err != nil {
+		return entity.Report{}, errors.Wrap(err, "find report")
+	}
+	if !exist {
+		return entity.Report{}, ErrorReportNotFound
+	}
+	if r.Status != constant.ReportOpen {
+		return entity.Report{}, ErrorReportResolved
+	}
+
+	if err := h.reports.Resolve(id, status, at); err != nil {
+		return entity.Report{}, errors.Wrap(err, "resolve report")
+	}
+	r.Status, r.ResolvedAt = status, &at
+
+	return *r, nil
+}
+
+func (h *ModerationHandler) SuspendPerson(ctx context.Context, id uint64) error {
+	p := h.person
+
+	p.gate.RLock()
+	defer p.gate.RUnlock()
+
+	return p.logged(wal.Record{Op: wal.OpSuspend, ID: id}, func(at time.Time) error {
+		return p.suspend(id, at)
+	})
+}
+
+// suspend takes the person out of its tree and ends their subscriptions with a removed
+// event, the person keeps the likes, passes and remaining dates for when the suspension
+// is lifted
+func (h *PersonHandler) suspend(id uint64, at time.Time) error {
+	// A match of the person in progress finishes first
+	unlock := h.lockPair(id, id)
+	defer unlock()
+
+	err := h.eachTree(func(t tree.Tree) error { return t.Suspend(id) })
+	switch {
+	case errors.Is(err, tree.ErrorPersonNotFound):
+		return ErrorPersonNotFound
+	case errors.Is(err, tree.ErrorPersonSuspended):
+		return ErrorPersonSuspended
+	case err != nil:
+		return err
+	}
+
+	if err := h.reports.ResolveReported(id, constant.ReportActioned, at); err != nil {
+		return errors.Wrap(err, "resolve reports")
+	}
+	h.notifyRemoved(at, id)
+	return nil
+}
+
+func (h *ModerationHandler) UnsuspendPerson(ctx context.Context, id uint64) error {
+	p := h.person
+
+	p.gate.RLock()
+	defer p.gate.RUnlock()
+
+	return p.logged(wal.Record{Op: wal.OpUnsuspend, ID: id}, func(at time.Time) error {
+		return p.unsuspend(id, at)
+	})
+}
+
+// unsuspend puts the person back among the candidates, telling the subscribers who see it again
+func (h *PersonHandler) unsuspend(id uint64, at time.Time) error {
+	err := h.eachTree(func(t tree.Tree) error { return t.Unsuspend(id) })
+	if errors.Is(err, tree.ErrorPersonNotFound) {
+		return ErrorPersonNotSuspended
+	}
+	if err != nil {
+		return err
+	}
+
+	if person, err := h.findPerson(id); err == nil {
+		h.notifyJoined(person, at, nil)
+	}
+	return nil
+}
+
+// eachTree calls fn on the trees in gender order until one does not return
+// tree.ErrorPersonNotFound, the person is in at most one of them
+func (h *PersonHandler) eachTree(fn func(t tree.Tree) error) error {
+	for _, g := range constant.Genders {
+		t, exist := h.trees[g]
+		if !exist {
+			continue
+		}
+		if err := fn(t); !errors.Is(err, tree.ErrorPersonNotFound) {
+			return err
+		}
+	}
+	return tree.ErrorPersonNotFound
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/util/cGin"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func (s *personTestSuite) Test_BlockHidesBothWays() {
+	h, people := s.initCouple()
+	s.expectCandidates(s.boys, people[0])
+	s.expectCandidates(s.girls, people[1])
+
+	_, err := h.Like(context.Background(), 2, 1)
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), h.Block(context.Background(), 1, 2))
+
+	for _, id := range []uint64{1, 2} {
+		page, err := h.QuerySinglePeople(context.Background(), id, CandidateQuery{Num: 5})
+		assert.Nil(s.T(), err)
+		assert.Empty(s.T(), page.Candidates, "blocked pair should be hidden from %d", id)
+	}
+
+	_, err = h.Like(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorBlocked, err)
+	assert.Equal(s.T(), ErrorBlocked, h.Pass(context.Background(), 2, 1), "the blocked side cannot react either")
+	_, err = h.Match(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorBlocked, err)
+
+	// Only the blocker can lift the block
+	assert.Nil(s.T(), h.Unblock(context.Background(), 2, 1))
+	_, err = h.Match(context.Background(), 2, 1)
+	assert.Equal(s.T(), ErrorBlocked, err)
+
+	assert.Nil(s.T(), h.Unblock(context.Background(), 1, 2))
+	page, err := h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 5})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Person{people[1]}, peopleOf(page.Candidates))
+}
+
+func (s *personTestSuite) Test_Report() {
+	h, _ := s.initCouple()
+
+	report, err := h.Report(context.Background(), 1, 2, "  spam  ")
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(1), report.ID)
+	assert.Equal(s.T(), "spam", report.Reason)
+	assert.Equal(s.T(), constant.ReportOpen, report.Status)
+	assert.False(s.T(), report.CreatedAt.IsZero())
+
+	for _, reason := range []string{" ", strings.Repeat("a", maxReasonLength+1)} {
+		_, err = h.Report(context.Background(), 1, 2, reason)
+		vErr, ok := err.(cGin.ValidationError)
+		if assert.True(s.T(), ok, "error should be a ValidationError") {
+			assert.Equal(s.T(), ErrorInvalidReport, vErr.CustomError)
+		}
+	}
+
+	_, err = h.Report(context.Background(), 1, 1, "spam")
+	assert.Equal(s.T(), ErrorMatchSamePerson, err)
+}
+
+func (s *personTestSuite) Test_Moderation() {
+	h, _ := s.initCouple()
+	m := NewModerationHandler(h)
+	ctx := context.Background()
+
+	first, err := h.Report(ctx, 1, 2, "spam")
+	assert.Nil(s.T(), err)
+	second, err := h.Report(ctx, 2, 1, "rude")
+	assert.Nil(s.T(), err)
+	_, err = h.Report(ctx, 1, 2, "spam again")
+	assert.Nil(s.T(), err)
+
+	dismissed, err := m.DismissReport(ctx, second.ID)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), constant.ReportDismissed, dismissed.Status)
+	assert.NotNil(s.T(), dismissed.ResolvedAt)
+	_, err = m.DismissReport(ctx, second.ID)
+	assert.Equal(s.T(), ErrorReportResolved, err)
+	_, err = m.DismissReport(ctx, 10)
+	assert.Equal(s.T(), ErrorReportNotFound, err)
+
+	// The person is looked for in every tree
+	s.boys.EXPECT().Suspend(uint64(2)).Return(tree.ErrorPersonNotFound).Times(2)
+	gomock.InOrder(
+		s.girls.EXPECT().Suspend(uint64(2)).Return(nil),
+		s.girls.EXPECT().Suspend(uint64(2)).Return(tree.ErrorPersonSuspended),
+	)
+	assert.Nil(s.T(), m.SuspendPerson(ctx, 2))
+	assert.Equal(s.T(), ErrorPersonSuspended, m.SuspendPerson(ctx, 2))
+
+	open, total, err := m.ListReports(ctx, constant.ReportOpen, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, total, "suspending should resolve the open reports")
+	assert.Empty(s.T(), open)
+	actioned, total, err := m.ListReports(ctx, constant.ReportActioned, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, total)
+	assert.Equal(s.T(), first.ID, actioned[0].ID)
+	_, _, err = m.ListReports(ctx, "closed", paging.Paginator{Limit: paging.Unlimited})
+	assert.Equal(s.T(), ErrorInvalidReport, err)
+
+	s.boys.EXPECT().Unsuspend(gomock.Any()).Return(tree.ErrorPersonNotFound).Times(2)
+	s.girls.EXPECT().Unsuspend(uint64(2)).Return(nil)
+	s.girls.EXPECT().Unsuspend(uint64(3)).Return(tree.ErrorPersonNotFound)
+	s.nonBinary.EXPECT().Unsuspend(uint64(3)).Return(tree.ErrorPersonNotFound)
+	assert.Nil(s.T(), m.UnsuspendPerson(ctx, 2))
+	assert.Equal(s.T(), ErrorPersonNotSuspended, m.UnsuspendPerson(ctx, 3))
+}
@@ -3,13 +3,21 @@ package usecase
 import (
 	"context"
 	"math"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 
 	"github.com/ars0915/matching-system/constant"
 	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/matchstore"
 	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/internal/wal"
+	"github.com/ars0915/matching-system/util/paging"
 )
 
 func (h *PersonHandler) GenerateNextID() uint64 {
@@ -17,144 +25,757 @@ func (h *PersonHandler) GenerateNextID() uint64 {
 }
 
 func (h *PersonHandler) AddPerson(p entity.Person) (entity.Person, error) {
-	var err error
+	withDefaultSeeking(&p)
+	normalizeAttributes(&p)
+	if err := validatePerson(p); err != nil {
+		return p, err
+	}
+
+	h.gate.RLock()
+	defer h.gate.RUnlock()
 
 	p.ID = h.GenerateNextID()
-	switch p.Gender {
-	case constant.GenderMale:
-		err = h.boys.AddPerson(&p)
-	case constant.GenderFemale:
-		err = h.girls.AddPerson(&p)
-	}
+	err := h.logged(wal.Record{Op: wal.OpAddPerson, Person: &p}, func(at time.Time) error {
+		return h.addPerson(&p, at)
+	})
 
 	return p, err
 }
 
-func (h *PersonHandler) findPerson(id uint64) (*entity.Person, error) {
-	if p, exist := h.boys.FindByID(id); exist {
-		return p, nil
+func (h *PersonHandler) CreatePerson(ctx context.Context, p entity.Person) (entity.Person, error) {
+	return h.AddPerson(p)
+}
+
+// GetPerson copies the person, suspended persons are not found
+func (h *PersonHandler) GetPerson(ctx context.Context, id uint64) (entity.Person, error) {
+	person, err := h.findPerson(id)
+	if err != nil {
+		return entity.Person{}, err
+	}
+
+	return copyPerson(*person), nil
+}
+
+// copyPerson copies p with its own wanted dates, which matches decrement atomically
+func copyPerson(p entity.Person) entity.Person {
+	wantedDates := atomic.LoadUint64(p.WantedDates)
+	p.WantedDates = &wantedDates
+	return p
+}
+
+// PersonQuery selects persons, zero fields do not filter
+type PersonQuery struct {
+	Gender    constant.Gender
+	MinHeight float64
+	MaxHeight float64
+}
+
+func (h *PersonHandler) ListPersons(ctx context.Context, q PersonQuery, page paging.Paginator) ([]entity.Person, int, error) {
+	if (q.Gender != "" && !validGender(q.Gender)) || !(q.MinHeight >= 0) || !(q.MaxHeight >= 0) ||
+		(q.MaxHeight > 0 && q.MinHeight > q.MaxHeight) {
+		return nil, 0, ErrorInvalidFilter
+	}
+	maxHeight := q.MaxHeight
+	if maxHeight == 0 {
+		maxHeight = math.MaxFloat64
+	}
+
+	var persons []entity.Person
+	h.moving.RLock()
+	for _, g := range constant.Genders {
+		t, exist := h.trees[g]
+		if !exist || (q.Gender != "" && q.Gender != g) {
+			continue
+		}
+		persons = append(persons, t.QueryByHeight(q.MinHeight, maxHeight)...)
+	}
+	h.moving.RUnlock()
+	// Each tree is in height order, merge them with the ID breaking ties
+	sort.Slice(persons, func(i, j int) bool {
+		if persons[i].Height != persons[j].Height {
+			return persons[i].Height < persons[j].Height
+		}
+		return persons[i].ID < persons[j].ID
+	})
+
+	total := len(persons)
+	start, end := page.Offset, total
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	if page.Limit >= 0 && start+page.Limit < end {
+		end = start + page.Limit
+	}
+
+	result := make([]entity.Person, 0, end-start)
+	for _, p := range persons[start:end] {
+		result = append(result, copyPerson(p))
+	}
+	return result, total, nil
+}
+
+// withDefaultSeeking fills in whom p seeks when it was not declared
+func withDefaultSeeking(p *entity.Person) {
+	if len(p.Seeking) == 0 {
+		p.Seeking = entity.DefaultSeeking(p.Gender)
+		p.SeekingDefaulted = true
+	}
+}
+
+// normalizeAttributes trims the city and tidies the tags so filters compare them as typed
+func normalizeAttributes(p *entity.Person) {
+	p.City = strings.TrimSpace(p.City)
+	p.Tags = normalizeTags(p.Tags)
+}
+
+// normalizeTags lowercases and trims tags, dropping the empty ones
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// treeOf returns the tree holding the persons of gender g
+func (h *PersonHandler) treeOf(g constant.Gender) (tree.Tree, error) {
+	t, exist := h.trees[g]
+	if !exist {
+		return nil, errors.Errorf("no tree for gender %q", g)
+	}
+	return t, nil
+}
+
+func (h *PersonHandler) addPerson(p *entity.Person, at time.Time) error {
+	t, err := h.treeOf(p.Gender)
+	if err != nil {
+		return err
 	}
-	if p, exist := h.girls.FindByID(id); exist {
-		return p, nil
+
+	if err := t.AddPerson(p); err != nil {
+		if errors.Is(err, tree.ErrorPersonExist) {
+			return ErrorPersonExist
+		}
+		return err
+	}
+	h.activity.touch(p.ID, at)
+	h.notifyJoined(p, at, nil)
+
+	return nil
+}
+
+func (h *PersonHandler) findPerson(id uint64) (*entity.Person, error) {
+	h.moving.RLock()
+	defer h.moving.RUnlock()
+
+	// Walk the genders in a fixed order rather than ranging over the map
+	for _, g := range constant.Genders {
+		t, exist := h.trees[g]
+		if !exist {
+			continue
+		}
+		if p, exist := t.FindByID(id); exist {
+			return p, nil
+		}
 	}
 
 	return nil, ErrorPersonNotFound
 }
 
 func (h *PersonHandler) RemovePerson(ctx context.Context, id uint64) error {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	return h.logged(wal.Record{Op: wal.OpRemovePerson, ID: id}, func(at time.Time) error {
+		return h.removePerson(id, at)
+	})
+}
+
+func (h *PersonHandler) removePerson(id uint64, at time.Time) error {
 	person, err := h.findPerson(id)
 	if err != nil {
 		return err
 	}
 
-	switch person.Gender {
-	case constant.GenderMale:
-		err = h.boys.RemovePerson(id)
-	case constant.GenderFemale:
-		err = h.girls.RemovePerson(id)
+	t, err := h.treeOf(person.Gender)
+	if err != nil {
+		return err
 	}
 
-	if err != nil {
+	if err := t.RemovePerson(id); err != nil {
 		if errors.Is(err, tree.ErrorPersonNotFound) {
 			return ErrorPersonNotFound
 		}
 		return err
 	}
+	h.activity.forget(id)
+	h.notifyRemoved(at, id)
 
 	return nil
 }
 
-func (h *PersonHandler) QuerySinglePeople(ctx context.Context, id uint64, num int) ([]entity.Person, error) {
-	var result []entity.Person
+func (h *PersonHandler) UpdatePerson(ctx context.Context, id uint64, patch entity.PersonPatch) (entity.Person, error) {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
 
-	person, err := h.findPerson(id)
+	var person entity.Person
+	err := h.logged(wal.Record{Op: wal.OpUpdatePerson, ID: id, Patch: &patch}, func(at time.Time) (err error) {
+		person, err = h.updatePerson(id, patch, at)
+		return err
+	})
+
+	return person, err
+}
+
+// updatePerson stores a patched copy of the person, so its tree moves it to the node
+// of the new height under the tree lock. A gender change moves it to another tree.
+func (h *PersonHandler) updatePerson(id uint64, patch entity.PersonPatch, at time.Time) (entity.Person, error) {
+	// A match of the person in progress finishes first and none starts on the old profile
+	unlock := h.lockPair(id, id)
+	defer unlock()
+
+	current, err := h.findPerson(id)
 	if err != nil {
-		return nil, err
+		return entity.Person{}, err
+	}
+	if current.Version != patch.Version {
+		return entity.Person{}, ErrorVersionConflict
 	}
 
-	switch person.Gender {
-	case constant.GenderMale:
-		result = h.girls.QueryByHeight(0, person.Height)
-	case constant.GenderFemale:
-		result = h.boys.QueryByHeight(person.Height, math.MaxFloat64)
+	before := copyPerson(*current)
+	updated := applyPatch(*current, patch)
+	if err := validatePerson(updated); err != nil {
+		return entity.Person{}, err
 	}
+	wantedDates := *updated.WantedDates
 
-	if len(result) > num {
-		return result[:num], nil
+	// The copy shares the wanted dates of the stored person, which matches decrement atomically
+	oldWantedDates := atomic.LoadUint64(current.WantedDates)
+	updated.WantedDates = current.WantedDates
+	atomic.StoreUint64(current.WantedDates, wantedDates)
+
+	if err := h.replacePerson(current, &updated); err != nil {
+		atomic.StoreUint64(current.WantedDates, oldWantedDates)
+		return entity.Person{}, err
 	}
+
+	result := updated
+	result.WantedDates = &wantedDates
+	// The persons the update brings into range of a subscriber join their candidates
+	h.events.index(id, result.Seeking)
+	h.notifyJoined(&result, at, &before)
 	return result, nil
 }
 
+// applyPatch returns a copy of p with the fields of patch and the next version
+func applyPatch(p entity.Person, patch entity.PersonPatch) entity.Person {
+	if patch.Name != nil {
+		p.Name = *patch.Name
+	}
+	if patch.Height != nil {
+		p.Height = *patch.Height
+	}
+	if patch.Gender != nil {
+		// A derived seeking follows the new gender unless the patch declares one
+		if *patch.Gender != p.Gender && p.SeekingDefaulted {
+			p.Seeking = entity.DefaultSeeking(*patch.Gender)
+		}
+		p.Gender = *patch.Gender
+	}
+	if patch.Seeking != nil {
+		p.Seeking = patch.Seeking
+		p.SeekingDefaulted = false
+	}
+
+	wantedDates := atomic.LoadUint64(p.WantedDates)
+	if patch.WantedDates != nil {
+		wantedDates = *patch.WantedDates
+	}
+	p.WantedDates = &wantedDates
+	p.Version++
+
+	return p
+}
+
+// replacePerson puts updated in place of current, moving it to the tree of its new gender
+func (h *PersonHandler) replacePerson(current, updated *entity.Person) error {
+	from, err := h.treeOf(current.Gender)
+	if err != nil {
+		return err
+	}
+	if updated.Gender == current.Gender {
+		return from.UpdatePerson(updated)
+	}
+
+	to, err := h.treeOf(updated.Gender)
+	if err != nil {
+		return err
+	}
+	// Reads across the trees wait, so they find the person in one tree or the other
+	h.moving.Lock()
+	defer h.moving.Unlock()
+
+	if err := from.RemovePerson(current.ID); err != nil {
+		return err
+	}
+	if err := to.AddPerson(updated); err != nil {
+		// Put the person back where it was
+		if rollbackErr := from.AddPerson(current); rollbackErr != nil {
+			return errors.Wrapf(err, "restore person: %v", rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// CandidateQuery selects a page of candidates
+type CandidateQuery struct {
+	Num int
+	// Sort orders the candidates, nearest height when empty
+	Sort constant.CandidateSort
+	// Cursor is the Next of the previous page, empty for the first page
+	Cursor string
+	Filter CandidateFilter
+}
+
+// CandidateFilter narrows the candidates down, zero fields do not filter
+type CandidateFilter struct {
+	MinAge int
+	MaxAge int
+	City   string
+	// Tags keeps the candidates with at least one of the tags
+	Tags []string
+	// MaxDistanceKm keeps the candidates within the distance of the person
+	MaxDistanceKm float64
+}
+
+// CandidatePage is a page of candidates
+type CandidatePage struct {
+	Candidates []Candidate
+	// Next is the cursor of the next page, empty when there are no more candidates
+	Next string
+}
+
+func (h *PersonHandler) QuerySinglePeople(ctx context.Context, id uint64, q CandidateQuery) (CandidatePage, error) {
+	order := q.Sort
+	if order == "" {
+		order = constant.SortNearest
+	}
+	if !validSort(order) {
+		return CandidatePage{}, ErrorInvalidSort
+	}
+
+	after, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return CandidatePage{}, err
+	}
+	// A random pick has no order to resume
+	if after != nil && order == constant.SortRandom {
+		return CandidatePage{}, ErrorInvalidCursor
+	}
+
+	if err := validateFilter(q.Filter); err != nil {
+		return CandidatePage{}, err
+	}
+
+	person, err := h.findPerson(id)
+	if err != nil {
+		return CandidatePage{}, err
+	}
+	if q.Num <= 0 {
+		return CandidatePage{}, nil
+	}
+	// A page is no larger than a page of ListPersons, which also keeps q.Num+1 from overflowing
+	if q.Num > paging.DefaultMaxLimit {
+		q.Num = paging.DefaultMaxLimit
+	}
+
+	filter, err := treeFilter(*person, q.Filter)
+	if err != nil {
+		return CandidatePage{}, err
+	}
+
+	hidden, err := h.hiddenSet(person.ID)
+	if err != nil {
+		return CandidatePage{}, err
+	}
+
+	// Keep the candidates who seek the person back
+	accept := func(candidate entity.Person) bool {
+		return candidate.ID != person.ID && candidate.Seeks(person.Gender) && !hidden[candidate.ID]
+	}
+
+	// Fetch one more than a page to know whether there is a next page
+	limit := q.Num + 1
+	if order == constant.SortRandom {
+		limit = q.Num
+	}
+
+	// Each tree of a sought gender gives its own top, then they are merged
+	var (
+		result []entity.Person
+		seen   []int
+		groups [][]entity.Person
+	)
+	h.moving.RLock()
+	for _, g := range person.Seeking {
+		t, exist := h.trees[g]
+		if !exist {
+			continue
+		}
+
+		tq := tree.Query{Filter: filter, After: after, Accept: accept}
+		tq.MinHeight, tq.MaxHeight = h.policy.CandidateRange(*person, g)
+		switch order {
+		case constant.SortNearest:
+			result = append(result, t.QueryNearest(person.Height, tq, limit)...)
+		case constant.SortTallest:
+			result = append(result, t.QueryOrdered(tq, true, limit)...)
+		case constant.SortShortest:
+			result = append(result, t.QueryOrdered(tq, false, limit)...)
+		case constant.SortRandom:
+			sample, n := t.QuerySample(tq, limit)
+			groups, seen = append(groups, sample), append(seen, n)
+		}
+	}
+	h.moving.RUnlock()
+
+	// Persons of the same height are ordered by ID, as the trees return them
+	switch order {
+	case constant.SortNearest:
+		sort.Slice(result, func(i, j int) bool {
+			di, dj := math.Abs(result[i].Height-person.Height), math.Abs(result[j].Height-person.Height)
+			if di != dj {
+				return di < dj
+			}
+			if result[i].Height != result[j].Height {
+				return result[i].Height < result[j].Height
+			}
+			return result[i].ID < result[j].ID
+		})
+	case constant.SortTallest:
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Height != result[j].Height {
+				return result[i].Height > result[j].Height
+			}
+			return result[i].ID < result[j].ID
+		})
+	case constant.SortShortest:
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Height != result[j].Height {
+				return result[i].Height < result[j].Height
+			}
+			return result[i].ID < result[j].ID
+		})
+	case constant.SortRandom:
+		result = mergeSamples(groups, seen, limit)
+	}
+
+	var page CandidatePage
+	if len(result) > q.Num {
+		last := result[q.Num-1]
+		result = result[:q.Num]
+		page.Next = encodeCursor(tree.Cursor{Height: last.Height, ID: last.ID})
+	}
+	page.Candidates = h.scoreCandidates(*person, result)
+
+	return page, nil
+}
+
+// treeFilter turns the filter of the candidates of person into the filter of the trees
+func treeFilter(person entity.Person, f CandidateFilter) (tree.Filter, error) {
+	filter := tree.Filter{
+		MinAge: f.MinAge,
+		MaxAge: f.MaxAge,
+		City:   strings.TrimSpace(f.City),
+		Tags:   normalizeTags(f.Tags),
+	}
+	if f.MaxDistanceKm > 0 {
+		if person.Location == nil {
+			return tree.Filter{}, ErrorLocationRequired
+		}
+		filter.Near, filter.MaxDistanceKm = person.Location, f.MaxDistanceKm
+	}
+	return filter, nil
+}
+
+func validSort(order constant.CandidateSort) bool {
+	switch order {
+	case constant.SortNearest, constant.SortTallest, constant.SortShortest, constant.SortRandom:
+		return true
+	}
+	return false
+}
+
+// mergeSamples draws up to num persons from the random samples of several trees.
+// Each pick comes from a tree with a chance proportional to how many of its
+// candidates are left, so every candidate is equally likely whichever tree holds it.
+func mergeSamples(samples [][]entity.Person, seen []int, num int) []entity.Person {
+	var (
+		result []entity.Person
+		total  int
+	)
+	for _, n := range seen {
+		total += n
+	}
+
+	for len(result) < num && total > 0 {
+		pick := rand.Intn(total)
+		for i := range samples {
+			if pick >= seen[i] {
+				pick -= seen[i]
+				continue
+			}
+
+			result = append(result, samples[i][0])
+			samples[i] = samples[i][1:]
+			seen[i]--
+			total--
+			break
+		}
+	}
+	return result
+}
+
 func (h *PersonHandler) AddPersonAndFindMatch(ctx context.Context, p entity.Person) ([]entity.Person, error) {
 	p, err := h.AddPerson(p)
 	if err != nil {
 		return nil, err
 	}
-	return h.QuerySinglePeople(ctx, p.ID, 1)
+	page, err := h.QuerySinglePeople(ctx, p.ID, CandidateQuery{Num: 1, Sort: constant.SortNearest})
+	if err != nil {
+		return nil, err
+	}
+
+	people := make([]entity.Person, len(page.Candidates))
+	for i, c := range page.Candidates {
+		people[i] = c.Person
+	}
+	return people, nil
+}
+
+func (h *PersonHandler) Match(ctx context.Context, id1, id2 uint64) (entity.Match, error) {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	var match entity.Match
+	err := h.logged(wal.Record{Op: wal.OpMutualMatch, ID1: id1, ID2: id2}, func(at time.Time) (err error) {
+		match, err = h.match(id1, id2, at, true)
+		return err
+	})
+
+	return match, err
 }
 
-func (h *PersonHandler) Match(ctx context.Context, id1, id2 uint64) error {
+// match pairs the two people, requireLike is false for matching rounds and
+// when replaying matches logged before both sides had to like each other
+func (h *PersonHandler) match(id1, id2 uint64, at time.Time, requireLike bool) (entity.Match, error) {
+	// Hold both people's locks so the checks, the two decrements, consuming the likes
+	// and recording the match happen as one step on the current profiles
+	unlock := h.lockPair(id1, id2)
+	defer unlock()
+
 	person1, err := h.findPerson(id1)
 	if err != nil {
-		return err
+		return entity.Match{}, err
 	}
 
 	person2, err := h.findPerson(id2)
 	if err != nil {
-		return err
+		return entity.Match{}, err
+	}
+
+	if person1.ID == person2.ID {
+		return entity.Match{}, ErrorMatchSamePerson
+	}
+
+	if !person1.CompatibleWith(*person2) {
+		return entity.Match{}, ErrorGenderIncompatible
+	}
+
+	if err := h.policy.Check(*person1, *person2); err != nil {
+		return entity.Match{}, err
+	}
+
+	return h.tryMatch(person1, person2, at, requireLike)
+}
+
+// tryMatch is called under the pair lock. When a step fails the steps before it are
+// undone, so the dates, the likes and the match history never hold half a match.
+func (h *PersonHandler) tryMatch(person1, person2 *entity.Person, at time.Time, requireLike bool) (_ entity.Match, err error) {
+	reactions, err := h.pairReactions(person1.ID, person2.ID)
+	if err != nil {
+		return entity.Match{}, err
+	}
+
+	if err := h.checkNotMatched(person1.ID, person2.ID); err != nil {
+		return entity.Match{}, err
+	}
+
+	if requireLike {
+		if err := h.checkMutualLike(person1.ID, person2.ID, at); err != nil {
+			return entity.Match{}, err
+		}
+	}
+
+	if atomic.LoadUint64(person1.WantedDates) == 0 || atomic.LoadUint64(person2.WantedDates) == 0 {
+		return entity.Match{}, ErrorWantedDateLimit
+	}
+
+	if !h.decrementWantedDate(person1) {
+		return entity.Match{}, ErrorWantedDateLimit
+	}
+	if !h.decrementWantedDate(person2) {
+		atomic.AddUint64(person1.WantedDates, 1)
+		return entity.Match{}, ErrorWantedDateLimit
+	}
+
+	var (
+		undo  []func()
+		saved []*entity.Person
+	)
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}()
+	undo = append(undo, func() {
+		atomic.AddUint64(person1.WantedDates, 1)
+		atomic.AddUint64(person2.WantedDates, 1)
+		for _, p := range saved {
+			if err := h.savePerson(p); err != nil {
+				logrus.WithField("personID", p.ID).WithError(err).Error("Restore wanted dates failed")
+			}
+		}
+	})
+
+	// Each like is spent on one match
+	undo = append(undo, func() {
+		for _, r := range reactions {
+			if err := h.reactions.Put(r); err != nil {
+				logrus.WithField("personID", r.PersonID).WithError(err).Error("Restore reaction failed")
+			}
+		}
+	})
+	if err := h.consumeLikes(person1.ID, person2.ID); err != nil {
+		return entity.Match{}, err
 	}
 
-	if person1.Gender == person2.Gender {
-		return ErrorMatchSameGender
+	// Persist the remaining dates before anyone is removed
+	match := entity.Match{
+		PersonID1: person1.ID,
+		PersonID2: person2.ID,
+		Status:    constant.MatchStatusMatched,
+		CreatedAt: at,
+	}
+	if err := h.saveMatch(&match, []*entity.Person{person1, person2}, &saved); err != nil {
+		return entity.Match{}, err
 	}
+	h.notifyMatched(match)
 
-	// Check height
-	if (person1.Gender == constant.GenderMale && person1.Height < person2.Height) ||
-		(person1.Gender == constant.GenderFemale && person1.Height > person2.Height) {
-		return ErrorHeightCheckFailed
+	// Remove from the system if any person's dates reach 0, the match stands either way
+	var exhausted []uint64
+	for _, p := range []*entity.Person{person1, person2} {
+		if h.removeIfExhausted(p) {
+			exhausted = append(exhausted, p.ID)
+		}
 	}
+	h.notifyRemoved(at, exhausted...)
+
+	return match, nil
+}
 
-	if !h.tryMatch(person1, person2) {
-		return ErrorWantedDateLimit
+// checkNotMatched returns ErrorAlreadyMatched when the pair was matched before and rematches are not allowed
+func (h *PersonHandler) checkNotMatched(id1, id2 uint64) error {
+	if h.allowRematch {
+		return nil
 	}
 
+	matched, err := h.matches.Matched(id1, id2)
+	if err != nil {
+		return errors.Wrap(err, "find match")
+	}
+	if matched {
+		return ErrorAlreadyMatched
+	}
 	return nil
 }
 
-func (h *PersonHandler) tryMatch(person1, person2 *entity.Person) bool {
-	// Atomically decrement wanted dates and check if either person has exhausted their dates
-	if !h.decrementWantedDate(person1) || !h.decrementWantedDate(person2) {
-		return false
+// lockPair locks the stripes of both ids in ascending order to avoid deadlock
+func (h *PersonHandler) lockPair(id1, id2 uint64) (unlock func()) {
+	i, j := id1%matchLockStripes, id2%matchLockStripes
+	if i > j {
+		i, j = j, i
 	}
 
-	// Remove from the system if any person's dates reach 0
-	h.removeIfExhausted(person1)
-	h.removeIfExhausted(person2)
+	h.matchLocks[i].Lock()
+	if i != j {
+		h.matchLocks[j].Lock()
+	}
 
-	return true
+	return func() {
+		if i != j {
+			h.matchLocks[j].Unlock()
+		}
+		h.matchLocks[i].Unlock()
+	}
 }
 
 func (h *PersonHandler) decrementWantedDate(person *entity.Person) bool {
-	current := atomic.LoadUint64(person.WantedDates)
-	if current == 0 {
-		return false
+	for {
+		current := atomic.LoadUint64(person.WantedDates)
+		if current == 0 {
+			return false
+		}
+
+		// Retry when the count changed between load and swap
+		if atomic.CompareAndSwapUint64(person.WantedDates, current, current-1) {
+			return true
+		}
 	}
+}
 
-	if atomic.CompareAndSwapUint64(person.WantedDates, current, current-1) {
-		return true
+// saveMatch writes the remaining dates of the persons and the match, in one transaction when the
+// match store keeps the persons too. The persons written on their own are added to saved.
+func (h *PersonHandler) saveMatch(match *entity.Match, persons []*entity.Person, saved *[]*entity.Person) error {
+	if ds, ok := h.matches.(matchstore.DatesStore); ok {
+		return errors.Wrap(ds.AddMatchWithDates(match, persons...), "add match")
 	}
-	return false
+
+	for _, p := range persons {
+		if err := h.savePerson(p); err != nil {
+			return errors.Wrap(err, "save person")
+		}
+		*saved = append(*saved, p)
+	}
+	return errors.Wrap(h.matches.AddMatch(match), "add match")
 }
 
-func (h *PersonHandler) removeIfExhausted(person *entity.Person) {
+func (h *PersonHandler) savePerson(person *entity.Person) error {
+	t, err := h.treeOf(person.Gender)
+	if err != nil {
+		return err
+	}
+	return t.UpdatePerson(person)
+}
+
+// removeIfExhausted reports whether it removed the person
+func (h *PersonHandler) removeIfExhausted(person *entity.Person) bool {
 	if atomic.LoadUint64(person.WantedDates) == 0 {
 		// Remove from the appropriate gender group
 		// Ignore the error because person has already been removed
-		switch person.Gender {
-		case constant.GenderMale:
-			_ = h.boys.RemovePerson(person.ID)
-		case constant.GenderFemale:
-			_ = h.girls.RemovePerson(person.ID)
+		if t, err := h.treeOf(person.Gender); err == nil && t.RemovePerson(person.ID) == nil {
+			h.activity.forget(person.ID)
+			return true
 		}
 	}
+	return false
 }
@@ -2,29 +2,45 @@ package usecase
 
 import (
 	"context"
+	"encoding/base64"
 	"math"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/ars0915/matching-system/constant"
 	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	matchMocks "github.com/ars0915/matching-system/internal/mocks/matchstore"
+	reactionMocks "github.com/ars0915/matching-system/internal/mocks/reactionstore"
+	reportMocks "github.com/ars0915/matching-system/internal/mocks/reportstore"
 	mocks "github.com/ars0915/matching-system/internal/mocks/tree"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/util/cGin"
 	ctest "github.com/ars0915/matching-system/util/cTest"
 	"github.com/ars0915/matching-system/util/cTypes"
+	"github.com/ars0915/matching-system/util/paging"
 )
 
 type personTestSuite struct {
 	suite.Suite
 	ctrl *gomock.Controller
 
-	h     *PersonHandler
-	boys  *mocks.MockTree
-	girls *mocks.MockTree
+	h         *PersonHandler
+	boys      *mocks.MockTree
+	girls     *mocks.MockTree
+	nonBinary *mocks.MockTree
+	matches   *matchMocks.MockStore
+	reactions *reactionMocks.MockStore
+	reports   *reportMocks.MockStore
 }
 
 func Test_personTestSuite(t *testing.T) {
@@ -35,22 +51,97 @@ func (s *personTestSuite) SetupTest() {
 	s.ctrl = gomock.NewController(s.T())
 	s.boys = mocks.NewMockTree(s.ctrl)
 	s.girls = mocks.NewMockTree(s.ctrl)
-	s.h = NewPersonHandler(s.boys, s.girls)
+	s.nonBinary = mocks.NewMockTree(s.ctrl)
+	s.matches = matchMocks.NewMockStore(s.ctrl)
+	s.reactions = reactionMocks.NewMockStore(s.ctrl)
+	s.reports = reportMocks.NewMockStore(s.ctrl)
+	// Nobody was matched before unless a test uses a real match store
+	s.matches.EXPECT().Matched(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	s.matches.EXPECT().Partners(gomock.Any()).Return(nil, nil).AnyTimes()
+	s.h = NewPersonHandler(s.trees(), s.matches, s.reactions, s.reports)
+}
+
+func (s *personTestSuite) trees() map[constant.Gender]tree.Tree {
+	return map[constant.Gender]tree.Tree{
+		constant.GenderMale:      s.boys,
+		constant.GenderFemale:    s.girls,
+		constant.GenderNonBinary: s.nonBinary,
+	}
+}
+
+func (s *personTestSuite) treeOf(g constant.Gender) *mocks.MockTree {
+	return map[constant.Gender]*mocks.MockTree{
+		constant.GenderMale:      s.boys,
+		constant.GenderFemale:    s.girls,
+		constant.GenderNonBinary: s.nonBinary,
+	}[g]
 }
 
 func (s *personTestSuite) TearDownTest(t *testing.T) {
 	defer s.ctrl.Finish()
 }
 
+// expectMutualLike makes the reactions store report that id1 and id2 like each other,
+// which is read once for blocks and once for the likes, and expects the likes to be
+// consumed by the match
+func (s *personTestSuite) expectMutualLike(id1, id2 uint64) {
+	for _, pair := range [][2]uint64{{id1, id2}, {id2, id1}} {
+		s.reactions.EXPECT().Find(pair[0], pair[1]).Return(&entity.Reaction{
+			PersonID:    pair[0],
+			CandidateID: pair[1],
+			Kind:        constant.ReactionLike,
+			CreatedAt:   time.Now(),
+		}, true, nil).Times(2)
+		s.reactions.EXPECT().Delete(pair[0], pair[1]).Return(nil).MaxTimes(1)
+	}
+}
+
+// expectCandidates makes m answer candidate queries from a real tree holding people
+func (s *personTestSuite) expectCandidates(m *mocks.MockTree, people ...entity.Person) {
+	pt := tree.NewPersonTree()
+	for i := range people {
+		p := people[i]
+		assert.Nil(s.T(), pt.AddPerson(&p))
+	}
+
+	m.EXPECT().QueryNearest(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(pt.QueryNearest).AnyTimes()
+	m.EXPECT().QueryOrdered(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(pt.QueryOrdered).AnyTimes()
+	m.EXPECT().QuerySample(gomock.Any(), gomock.Any()).DoAndReturn(pt.QuerySample).AnyTimes()
+}
+
+// initPeople adds people and writes the added persons back, so the fixtures
+// carry the defaults the trees would hold
 func (s *personTestSuite) initPeople(people []entity.Person) {
-	for _, person := range people {
-		if person.Gender == constant.GenderMale {
-			s.boys.EXPECT().AddPerson(gomock.Any()).Return(nil)
-		} else {
-			s.girls.EXPECT().AddPerson(gomock.Any()).Return(nil)
-		}
-		_, err := s.h.AddPerson(person)
+	for i := range people {
+		s.treeOf(people[i].Gender).EXPECT().AddPerson(gomock.Any()).Return(nil)
+		added, err := s.h.AddPerson(people[i])
 		assert.Nil(s.T(), err)
+		people[i] = added
+	}
+}
+
+// newTestHandler returns a PersonHandler on empty in-memory trees and stores, for the
+// tests that run the real ones rather than the mocks of personTestSuite
+func newTestHandler(t *testing.T, opts ...PersonHandlerOption) *PersonHandler {
+	t.Helper()
+	trees := map[constant.Gender]tree.Tree{}
+	for _, g := range constant.Genders {
+		trees[g] = tree.NewPersonTree()
+	}
+	return NewPersonHandler(trees, matchstore.NewMatchStore(), reactionstore.NewReactionStore(), reportstore.NewReportStore(), opts...)
+}
+
+// withTree replaces the tree of gender g, for a tree that holds up or fails its calls
+func withTree(g constant.Gender, t tree.Tree) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.trees[g] = t
+	}
+}
+
+// withMatchStore replaces the match store, for the mock of personTestSuite
+func withMatchStore(s matchstore.Store) PersonHandlerOption {
+	return func(h *PersonHandler) {
+		h.matches = s
 	}
 }
 
@@ -60,6 +151,7 @@ func (s *personTestSuite) Test_AddPerson() {
 		Name:        "a",
 		Height:      150,
 		Gender:      "male",
+		Seeking:     []constant.Gender{constant.GenderFemale},
 		WantedDates: cTypes.Uint64(2),
 	}
 
@@ -70,6 +162,19 @@ func (s *personTestSuite) Test_AddPerson() {
 	assert.Equal(s.T(), person, actualPerson)
 }
 
+func (s *personTestSuite) Test_AddPersonDefaultSeeking() {
+	s.girls.EXPECT().AddPerson(gomock.Any()).Return(nil)
+
+	person, err := s.h.AddPerson(entity.Person{
+		Name:        "a",
+		Height:      160,
+		Gender:      constant.GenderFemale,
+		WantedDates: cTypes.Uint64(1),
+	})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []constant.Gender{constant.GenderMale}, person.Seeking)
+}
+
 func (s *personTestSuite) Test_RemovePerson() {
 	person := entity.Person{
 		ID:          1,
@@ -87,6 +192,15 @@ func (s *personTestSuite) Test_RemovePerson() {
 	assert.Nil(s.T(), err)
 }
 
+// peopleOf drops the scores of candidates
+func peopleOf(candidates []Candidate) []entity.Person {
+	people := make([]entity.Person, len(candidates))
+	for i, c := range candidates {
+		people[i] = c.Person
+	}
+	return people
+}
+
 func (s *personTestSuite) Test_QuerySinglePeople() {
 	people := []entity.Person{
 		{
@@ -123,14 +237,254 @@ func (s *personTestSuite) Test_QuerySinglePeople() {
 	targetPerson := people[3]
 	s.boys.EXPECT().FindByID(targetPerson.ID).Return(nil, false)
 	s.girls.EXPECT().FindByID(targetPerson.ID).Return(&targetPerson, true)
-	s.boys.EXPECT().QueryByHeight(targetPerson.Height, math.MaxFloat64).Return(people[:3])
+	s.reactions.EXPECT().Passed(targetPerson.ID).Return(nil, nil)
+	s.reactions.EXPECT().Blocked(targetPerson.ID).Return(nil, nil)
+	s.boys.EXPECT().QueryNearest(targetPerson.Height, gomock.Any(), 3).
+		DoAndReturn(func(target float64, q tree.Query, k int) []entity.Person {
+			assert.Equal(s.T(), targetPerson.Height, q.MinHeight)
+			assert.Equal(s.T(), math.MaxFloat64, q.MaxHeight)
+			assert.Nil(s.T(), q.After)
+			return people[:k]
+		})
+
+	page, err := s.h.QuerySinglePeople(context.Background(), targetPerson.ID, CandidateQuery{Num: 2})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), people[:2], peopleOf(page.Candidates))
+	assert.Equal(s.T(), encodeCursor(tree.Cursor{Height: 152, ID: 2}), page.Next)
+
+	// Both just joined and neither has tags or a location
+	breakdown := page.Candidates[0].Breakdown
+	if assert.Len(s.T(), breakdown, 2) {
+		assert.Equal(s.T(), constant.ScorerActivity, breakdown[0].Name)
+		assert.InDelta(s.T(), 1, breakdown[0].Score, 0.01)
+		assert.Equal(s.T(), constant.ScorerHeightGap, breakdown[1].Name)
+		assert.InDelta(s.T(), 29.0/30, breakdown[1].Score, 1e-9)
+	}
+	assert.InDelta(s.T(), (breakdown[0].Score+breakdown[1].Score)/2, page.Candidates[0].Score, 1e-9)
+	assert.Greater(s.T(), page.Candidates[0].Score, page.Candidates[1].Score, "the smaller height gap should score higher")
+}
+
+func (s *personTestSuite) Test_QuerySinglePeopleInvalidQuery() {
+	_, err := s.h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 2, Sort: "oldest"})
+	assert.Equal(s.T(), ErrorInvalidSort, err)
+
+	_, err = s.h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 2, Cursor: "%%"})
+	assert.Equal(s.T(), ErrorInvalidCursor, err)
 
-	gotPeople, err := s.h.QuerySinglePeople(context.Background(), targetPerson.ID, 2)
+	// A forged cursor with a height no tree can seek to
+	for _, raw := range []string{"NaN,1", "Inf,1", "+Inf,1", "-Inf,1"} {
+		cursor := base64.RawURLEncoding.EncodeToString([]byte(raw))
+		_, err = s.h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 2, Cursor: cursor})
+		assert.Equal(s.T(), ErrorInvalidCursor, err, raw)
+	}
+
+	cursor := encodeCursor(tree.Cursor{Height: 170, ID: 1})
+	_, err = s.h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 2, Sort: constant.SortRandom, Cursor: cursor})
+	assert.Equal(s.T(), ErrorInvalidCursor, err)
+}
+
+func (s *personTestSuite) Test_QuerySinglePeopleFilter() {
+	seekingFemale := []constant.Gender{constant.GenderFemale}
+	seekingMale := []constant.Gender{constant.GenderMale}
+	taipei := &entity.Location{Latitude: 25.033, Longitude: 121.565}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, Seeking: seekingFemale, WantedDates: cTypes.Uint64(1), Location: taipei},
+		{ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1),
+			Age: 25, City: "Taipei", Tags: []string{"jazz"}, Location: &entity.Location{Latitude: 25.047, Longitude: 121.517}},
+		{ID: 3, Name: "c", Height: 165, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1),
+			Age: 35, City: "Tainan", Tags: []string{"Hiking "}, Location: &entity.Location{Latitude: 22.999, Longitude: 120.227}},
+		{ID: 4, Name: "d", Height: 170, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+	}
+	s.initPeople(people)
+	assert.Equal(s.T(), []string{"hiking"}, people[2].Tags, "tags should be normalized")
+
+	target := people[0]
+	s.boys.EXPECT().FindByID(target.ID).Return(&target, true).AnyTimes()
+	s.reactions.EXPECT().Passed(target.ID).Return(nil, nil).AnyTimes()
+	s.reactions.EXPECT().Blocked(target.ID).Return(nil, nil).AnyTimes()
+	s.expectCandidates(s.girls, people[1:]...)
+
+	tests := []struct {
+		name   string
+		filter CandidateFilter
+		want   []uint64
+	}{
+		{"No filter", CandidateFilter{}, []uint64{4, 3, 2}},
+		{"Age", CandidateFilter{MinAge: 30}, []uint64{3}},
+		{"City", CandidateFilter{City: " taipei"}, []uint64{2}},
+		{"Tags", CandidateFilter{Tags: []string{"HIKING", "chess"}}, []uint64{3}},
+		{"Distance", CandidateFilter{MaxDistanceKm: 20}, []uint64{2}},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			page, err := s.h.QuerySinglePeople(context.Background(), target.ID, CandidateQuery{Num: 5, Filter: tt.filter})
+			assert.Nil(t, err)
+
+			var got []uint64
+			for _, p := range peopleOf(page.Candidates) {
+				got = append(got, p.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, f := range []CandidateFilter{{MinAge: 40, MaxAge: 30}, {MaxDistanceKm: math.NaN()}, {MaxDistanceKm: math.Inf(1)}} {
+		_, err := s.h.QuerySinglePeople(context.Background(), target.ID, CandidateQuery{Num: 5, Filter: f})
+		assert.Equal(s.T(), ErrorInvalidFilter, err)
+	}
+
+	target.Location = nil
+	_, err := s.h.QuerySinglePeople(context.Background(), target.ID, CandidateQuery{Num: 5, Filter: CandidateFilter{MaxDistanceKm: 20}})
+	assert.Equal(s.T(), ErrorLocationRequired, err)
+}
+
+func (s *personTestSuite) Test_QuerySinglePeoplePages() {
+	seekingFemale := []constant.Gender{constant.GenderFemale}
+	seekingMale := []constant.Gender{constant.GenderMale}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 170, Gender: constant.GenderMale, Seeking: seekingFemale, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+		{ID: 3, Name: "c", Height: 165, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+		{ID: 4, Name: "d", Height: 165, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+		{ID: 5, Name: "e", Height: 150, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+		{ID: 6, Name: "f", Height: 168, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+	}
+	s.initPeople(people)
+
+	target := people[0]
+	s.boys.EXPECT().FindByID(target.ID).Return(&target, true).AnyTimes()
+	s.reactions.EXPECT().Passed(target.ID).Return(nil, nil).AnyTimes()
+	s.reactions.EXPECT().Blocked(target.ID).Return(nil, nil).AnyTimes()
+	s.expectCandidates(s.girls, people[1:]...)
+
+	tests := []struct {
+		sort constant.CandidateSort
+		want []uint64
+	}{
+		{constant.SortNearest, []uint64{6, 3, 4, 2, 5}},
+		{constant.SortShortest, []uint64{5, 2, 3, 4, 6}},
+		{constant.SortTallest, []uint64{6, 3, 4, 2, 5}},
+	}
+	for _, tt := range tests {
+		s.T().Run(string(tt.sort), func(t *testing.T) {
+			var (
+				got   []uint64
+				q     = CandidateQuery{Num: 2, Sort: tt.sort}
+				pages int
+			)
+			for {
+				page, err := s.h.QuerySinglePeople(context.Background(), target.ID, q)
+				assert.Nil(t, err)
+				for _, p := range peopleOf(page.Candidates) {
+					got = append(got, p.ID)
+				}
+				pages++
+				if page.Next == "" {
+					break
+				}
+				q.Cursor = page.Next
+			}
+			assert.Equal(t, tt.want, got, "pages should continue where the last one stopped")
+			assert.Equal(t, 3, pages)
+		})
+	}
+
+	// A num too large for one more to be fetched is a single page of everyone
+	page, err := s.h.QuerySinglePeople(context.Background(), target.ID, CandidateQuery{Num: math.MaxInt})
 	assert.Nil(s.T(), err)
-	assert.Equal(s.T(), 2, len(gotPeople))
+	var got []uint64
+	for _, p := range peopleOf(page.Candidates) {
+		got = append(got, p.ID)
+	}
+	assert.Equal(s.T(), []uint64{6, 3, 4, 2, 5}, got)
+	assert.Empty(s.T(), page.Next)
+}
+
+func (s *personTestSuite) Test_QuerySinglePeopleSeekingManyGenders() {
+	seekingAll := []constant.Gender{constant.GenderMale, constant.GenderFemale, constant.GenderNonBinary}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 175, Gender: constant.GenderNonBinary, Seeking: seekingAll, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "b", Height: 180, Gender: constant.GenderMale, Seeking: seekingAll, WantedDates: cTypes.Uint64(1)},
+		{ID: 3, Name: "c", Height: 160, Gender: constant.GenderFemale, Seeking: seekingAll, WantedDates: cTypes.Uint64(1)},
+		// Does not seek non-binary people, so never shown to them
+		{ID: 4, Name: "d", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+		{ID: 5, Name: "e", Height: 165, Gender: constant.GenderNonBinary, Seeking: seekingAll, WantedDates: cTypes.Uint64(1)},
+	}
+	s.initPeople(people)
+
+	target := people[0]
+	s.boys.EXPECT().FindByID(target.ID).Return(nil, false).Times(4)
+	s.girls.EXPECT().FindByID(target.ID).Return(nil, false).Times(4)
+	s.nonBinary.EXPECT().FindByID(target.ID).Return(&target, true).Times(4)
+	s.reactions.EXPECT().Passed(target.ID).Return(nil, nil).Times(4)
+	s.reactions.EXPECT().Blocked(target.ID).Return(nil, nil).Times(4)
+	s.expectCandidates(s.boys, people[1], people[3])
+	s.expectCandidates(s.girls, people[2])
+	s.expectCandidates(s.nonBinary, people[0], people[4])
+
+	tests := []struct {
+		sort constant.CandidateSort
+		want []entity.Person
+	}{
+		{constant.SortNearest, []entity.Person{people[1], people[4], people[2]}},
+		{constant.SortShortest, []entity.Person{people[2], people[4], people[1]}},
+		{constant.SortTallest, []entity.Person{people[1], people[4], people[2]}},
+	}
+	for _, tt := range tests {
+		s.T().Run(string(tt.sort), func(t *testing.T) {
+			page, err := s.h.QuerySinglePeople(context.Background(), target.ID, CandidateQuery{Num: 10, Sort: tt.sort})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, peopleOf(page.Candidates), "candidates of every tree should be merged in order")
+		})
+	}
+
+	// A random pick holds only accepted candidates
+	page, err := s.h.QuerySinglePeople(context.Background(), target.ID, CandidateQuery{Num: 2, Sort: constant.SortRandom})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), peopleOf(page.Candidates), 2)
+	assert.Subset(s.T(), []entity.Person{people[1], people[2], people[4]}, peopleOf(page.Candidates))
+	assert.Empty(s.T(), page.Next, "a random pick has no next page")
+}
+
+func (s *personTestSuite) Test_MatchSameGenderSeekingEachOther() {
+	seekingFemale := []constant.Gender{constant.GenderFemale}
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 150, Gender: constant.GenderFemale, Seeking: seekingFemale, WantedDates: cTypes.Uint64(2)},
+		{ID: 2, Name: "b", Height: 170, Gender: constant.GenderFemale, Seeking: seekingFemale, WantedDates: cTypes.Uint64(2)},
+	}
+	s.initPeople(people)
+
+	s.boys.EXPECT().FindByID(gomock.Any()).Return(nil, false).Times(2)
+	s.girls.EXPECT().FindByID(people[0].ID).Return(&people[0], true)
+	s.girls.EXPECT().FindByID(people[1].ID).Return(&people[1], true)
+	s.girls.EXPECT().UpdatePerson(gomock.Any()).Return(nil).Times(2)
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil)
+
+	s.expectMutualLike(1, 2)
+	_, err := s.h.Match(context.Background(), 1, 2)
+	assert.Nil(s.T(), err, "same gender pairs seeking each other should match, the height rule only binds male and female")
+}
+
+func (s *personTestSuite) Test_MatchSamePerson() {
+	person := entity.Person{
+		ID:          1,
+		Name:        "a",
+		Height:      170,
+		Gender:      constant.GenderNonBinary,
+		Seeking:     []constant.Gender{constant.GenderNonBinary},
+		WantedDates: cTypes.Uint64(2),
+	}
+	s.initPeople([]entity.Person{person})
+
+	s.boys.EXPECT().FindByID(person.ID).Return(nil, false).Times(2)
+	s.girls.EXPECT().FindByID(person.ID).Return(nil, false).Times(2)
+	s.nonBinary.EXPECT().FindByID(person.ID).Return(&person, true).Times(2)
+
+	_, err := s.h.Match(context.Background(), 1, 1)
+	assert.Equal(s.T(), ErrorMatchSamePerson, err)
 }
 
-func (s *personTestSuite) Test_MatchSameGender() {
+func (s *personTestSuite) Test_MatchIncompatibleGender() {
 	people := []entity.Person{
 		{
 			ID:          1,
@@ -152,8 +506,8 @@ func (s *personTestSuite) Test_MatchSameGender() {
 	s.boys.EXPECT().FindByID(people[0].ID).Return(&people[0], true)
 	s.boys.EXPECT().FindByID(people[1].ID).Return(&people[1], true)
 
-	err := s.h.Match(context.Background(), 1, 2)
-	assert.Equal(s.T(), ErrorMatchSameGender, err)
+	_, err := s.h.Match(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorGenderIncompatible, err)
 }
 
 func (s *personTestSuite) Test_MatchHeightCheckFail() {
@@ -179,7 +533,7 @@ func (s *personTestSuite) Test_MatchHeightCheckFail() {
 	s.boys.EXPECT().FindByID(people[1].ID).Return(nil, false)
 	s.girls.EXPECT().FindByID(people[1].ID).Return(&people[1], true)
 
-	err := s.h.Match(context.Background(), 1, 2)
+	_, err := s.h.Match(context.Background(), 1, 2)
 	assert.Equal(s.T(), ErrorHeightCheckFailed, err)
 }
 
@@ -190,7 +544,7 @@ func (s *personTestSuite) Test_MatchDecrementWantedDatesFail() {
 			Name:        "a",
 			Height:      170,
 			Gender:      "male",
-			WantedDates: cTypes.Uint64(0),
+			WantedDates: cTypes.Uint64(1),
 		},
 		{
 			ID:          2,
@@ -201,12 +555,15 @@ func (s *personTestSuite) Test_MatchDecrementWantedDatesFail() {
 		},
 	}
 	s.initPeople(people)
+	// Dates used up after joining
+	*people[0].WantedDates = 0
 
 	s.boys.EXPECT().FindByID(people[0].ID).Return(&people[0], true)
 	s.boys.EXPECT().FindByID(people[1].ID).Return(nil, false)
 	s.girls.EXPECT().FindByID(people[1].ID).Return(&people[1], true)
 
-	err := s.h.Match(context.Background(), 1, 2)
+	s.expectMutualLike(1, 2)
+	_, err := s.h.Match(context.Background(), 1, 2)
 	assert.Equal(s.T(), ErrorWantedDateLimit, err)
 }
 
@@ -233,8 +590,20 @@ func (s *personTestSuite) Test_MatchSuccess() {
 	s.boys.EXPECT().FindByID(people[1].ID).Return(nil, false)
 	s.girls.EXPECT().FindByID(people[1].ID).Return(&people[1], true)
 
-	err := s.h.Match(context.Background(), 1, 2)
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil)
+	s.matches.EXPECT().AddMatch(gomock.Any()).DoAndReturn(func(m *entity.Match) error {
+		m.ID = 1
+		return nil
+	})
+
+	s.expectMutualLike(1, 2)
+	match, err := s.h.Match(context.Background(), 1, 2)
 	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(1), match.ID)
+	assert.Equal(s.T(), people[0].ID, match.PersonID1)
+	assert.Equal(s.T(), people[1].ID, match.PersonID2)
+	assert.Equal(s.T(), constant.MatchStatusMatched, match.Status)
 }
 
 func (s *personTestSuite) Test_MatchWithRemovePerson() {
@@ -260,10 +629,65 @@ func (s *personTestSuite) Test_MatchWithRemovePerson() {
 	s.boys.EXPECT().FindByID(people[1].ID).Return(nil, false)
 	s.girls.EXPECT().FindByID(people[1].ID).Return(&people[1], true)
 
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil)
 	s.boys.EXPECT().RemovePerson(people[0].ID).Return(nil)
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil)
+
+	s.expectMutualLike(1, 2)
+	_, err := s.h.Match(context.Background(), 1, 2)
+	assert.Nil(s.T(), err)
+}
+
+func (s *personTestSuite) Test_MatchRollback() {
+	h, people := s.initCouple()
+	*people[0].WantedDates = 1
+	ctx := context.Background()
+	failure := errors.New("disk full")
 
-	err := s.h.Match(context.Background(), 1, 2)
+	// A mutual like is matched right away, so put the likes straight into the store
+	for _, like := range [][2]uint64{{1, 2}, {2, 1}} {
+		err := h.reactions.Put(entity.Reaction{PersonID: like[0], CandidateID: like[1], Kind: constant.ReactionLike, CreatedAt: time.Now()})
+		assert.Nil(s.T(), err)
+	}
+
+	// The match record fails after the dates were saved and the likes consumed
+	gomock.InOrder(
+		s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil),
+		s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil),
+	)
+	gomock.InOrder(
+		s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil),
+		s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil),
+	)
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(failure)
+	_, err := h.Match(ctx, 1, 2)
+	assert.Equal(s.T(), failure, errors.Cause(err))
+	assert.Equal(s.T(), uint64(1), *people[0].WantedDates, "the dates should be given back")
+	assert.Equal(s.T(), uint64(2), *people[1].WantedDates)
+	assert.Nil(s.T(), h.checkMutualLike(1, 2, time.Now()), "the likes should be put back")
+
+	// Saving the second person fails after the first one was saved
+	gomock.InOrder(
+		s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil),
+		s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil),
+	)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(failure)
+	_, err = h.Match(ctx, 1, 2)
+	assert.Equal(s.T(), failure, errors.Cause(err))
+	assert.Equal(s.T(), uint64(1), *people[0].WantedDates)
+	assert.Equal(s.T(), uint64(2), *people[1].WantedDates)
+	assert.Nil(s.T(), h.checkMutualLike(1, 2, time.Now()))
+
+	// With nothing failing the match goes through once
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil)
+	s.boys.EXPECT().RemovePerson(people[0].ID).Return(nil)
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil)
+	_, err = h.Match(ctx, 1, 2)
 	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(0), *people[0].WantedDates)
+	assert.Equal(s.T(), uint64(1), *people[1].WantedDates)
 }
 
 func (s *personTestSuite) Test_Concurrent_DecrementWantedDate() {
@@ -289,3 +713,254 @@ func (s *personTestSuite) Test_Concurrent_DecrementWantedDate() {
 	actualWantedDates := atomic.LoadUint64(person.WantedDates)
 	assert.Equal(s.T(), uint64(3), actualWantedDates)
 }
+
+func (s *personTestSuite) Test_Concurrent_Match() {
+	var boys, girls []entity.Person
+	for i := 1; i <= 5; i++ {
+		boys = append(boys, entity.Person{
+			ID:          uint64(i),
+			Name:        "boy",
+			Height:      180,
+			Gender:      constant.GenderMale,
+			Seeking:     []constant.Gender{constant.GenderFemale},
+			WantedDates: cTypes.Uint64(uint64(i)),
+		})
+		girls = append(girls, entity.Person{
+			ID:          uint64(100 + i),
+			Name:        "girl",
+			Height:      160,
+			Gender:      constant.GenderFemale,
+			Seeking:     []constant.Gender{constant.GenderMale},
+			WantedDates: cTypes.Uint64(uint64(6 - i)),
+		})
+	}
+
+	var initialDates uint64
+	people := map[uint64]*entity.Person{}
+	for i := range boys {
+		people[boys[i].ID] = &boys[i]
+		people[girls[i].ID] = &girls[i]
+		initialDates += *boys[i].WantedDates + *girls[i].WantedDates
+	}
+
+	s.boys.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		p, exist := people[id]
+		if !exist || p.Gender != constant.GenderMale {
+			return nil, false
+		}
+		return p, true
+	}).AnyTimes()
+	s.girls.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		p, exist := people[id]
+		if !exist || p.Gender != constant.GenderFemale {
+			return nil, false
+		}
+		return p, true
+	}).AnyTimes()
+	s.boys.EXPECT().UpdatePerson(gomock.Any()).Return(nil).AnyTimes()
+	s.girls.EXPECT().UpdatePerson(gomock.Any()).Return(nil).AnyTimes()
+	s.boys.EXPECT().RemovePerson(gomock.Any()).Return(nil).AnyTimes()
+	s.girls.EXPECT().RemovePerson(gomock.Any()).Return(nil).AnyTimes()
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil).AnyTimes()
+	s.reactions.EXPECT().Find(gomock.Any(), gomock.Any()).DoAndReturn(func(personID, candidateID uint64) (*entity.Reaction, bool, error) {
+		return &entity.Reaction{PersonID: personID, CandidateID: candidateID, Kind: constant.ReactionLike, CreatedAt: time.Now()}, true, nil
+	}).AnyTimes()
+	s.reactions.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	var (
+		wg      sync.WaitGroup
+		success uint64
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			boy := boys[i%len(boys)]
+			girl := girls[(i/len(boys))%len(girls)]
+			if _, err := s.h.Match(context.Background(), boy.ID, girl.ID); err == nil {
+				atomic.AddUint64(&success, 1)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	var remainingDates uint64
+	for _, p := range people {
+		remainingDates += atomic.LoadUint64(p.WantedDates)
+	}
+	assert.Equal(s.T(), 2*success, initialDates-remainingDates)
+}
+
+func Test_UpdatePerson(t *testing.T) {
+	h := newTestHandler(t)
+	boys, girls := h.trees[constant.GenderMale], h.trees[constant.GenderFemale]
+
+	p, err := h.AddPerson(entity.Person{Name: "a", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(2)})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), p.Version)
+
+	name, height := "b", 182.5
+	updated, err := h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Name: &name, Height: &height, Version: 0})
+	assert.Nil(t, err)
+	assert.Equal(t, "b", updated.Name)
+	assert.Equal(t, uint64(1), updated.Version)
+	assert.Equal(t, uint64(2), *updated.WantedDates)
+	assert.Empty(t, boys.QueryByHeight(170, 170), "the old height node should be left")
+	assert.Equal(t, []entity.Person{updated}, boys.QueryByHeight(182.5, 182.5))
+
+	_, err = h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Name: &name, Version: 0})
+	assert.Equal(t, ErrorVersionConflict, err)
+
+	// A gender change moves the person to the other tree and keeps the dates shared with matches
+	gender, wantedDates := constant.GenderFemale, uint64(5)
+	updated, err = h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{
+		Gender:      &gender,
+		Seeking:     []constant.Gender{constant.GenderMale},
+		WantedDates: &wantedDates,
+		Version:     1,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), updated.Version)
+	assert.Empty(t, boys.Snapshot())
+	stored, exist := girls.FindByID(p.ID)
+	assert.True(t, exist, "person should be in the tree of the new gender")
+	assert.Equal(t, uint64(5), atomic.LoadUint64(stored.WantedDates))
+
+	height = 10
+	_, err = h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Height: &height, Version: 2})
+	vErr, ok := err.(cGin.ValidationError)
+	if assert.True(t, ok, "error should be a ValidationError") {
+		assert.Equal(t, "height", vErr.Fields[0].Field)
+	}
+
+	_, err = h.UpdatePerson(context.Background(), 99, entity.PersonPatch{Name: &name})
+	assert.Equal(t, ErrorPersonNotFound, err)
+}
+
+func (s *personTestSuite) Test_UpdatePersonGenderRollback() {
+	h, people := s.initCouple()
+	gender := constant.GenderFemale
+
+	s.boys.EXPECT().RemovePerson(people[0].ID).Return(nil)
+	s.girls.EXPECT().AddPerson(gomock.Any()).Return(errors.New("disk full"))
+	s.boys.EXPECT().AddPerson(&people[0]).Return(nil)
+
+	_, err := h.UpdatePerson(context.Background(), people[0].ID, entity.PersonPatch{Gender: &gender, Seeking: people[0].Seeking})
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), constant.GenderMale, people[0].Gender)
+	assert.Equal(s.T(), uint64(0), people[0].Version)
+}
+
+func Test_UpdatePersonGenderDefaultSeeking(t *testing.T) {
+	h := newTestHandler(t)
+	female, male := constant.GenderFemale, constant.GenderMale
+
+	// A seeking that was never declared follows the gender
+	p, err := h.AddPerson(entity.Person{Name: "a", Height: 170, Gender: male, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	updated, err := h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Gender: &female, Version: 0})
+	assert.Nil(t, err)
+	assert.Equal(t, []constant.Gender{constant.GenderMale}, updated.Seeking)
+	assert.True(t, updated.SeekingDefaulted)
+
+	// A declared one is kept
+	updated, err = h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Seeking: []constant.Gender{constant.GenderMale}, Version: 1})
+	assert.Nil(t, err)
+	assert.False(t, updated.SeekingDefaulted)
+	updated, err = h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Gender: &male, Version: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []constant.Gender{constant.GenderMale}, updated.Seeking)
+
+	p, err = h.AddPerson(entity.Person{Name: "b", Height: 160, Gender: female, Seeking: []constant.Gender{constant.GenderMale}, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+	updated, err = h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Gender: &male, Version: 0})
+	assert.Nil(t, err)
+	assert.Equal(t, []constant.Gender{constant.GenderMale}, updated.Seeking)
+}
+
+// slowAddTree holds up AddPerson after telling adding, to catch a person between trees
+type slowAddTree struct {
+	*tree.PersonTree
+	adding chan struct{}
+}
+
+func (t slowAddTree) AddPerson(p *entity.Person) error {
+	close(t.adding)
+	time.Sleep(50 * time.Millisecond)
+	return t.PersonTree.AddPerson(p)
+}
+
+func Test_UpdatePersonGenderConcurrentRead(t *testing.T) {
+	girls := slowAddTree{PersonTree: tree.NewPersonTree(), adding: make(chan struct{})}
+	h := newTestHandler(t, withTree(constant.GenderFemale, girls))
+	p, err := h.AddPerson(entity.Person{Name: "a", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(t, err)
+
+	gender := constant.GenderFemale
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.UpdatePerson(context.Background(), p.ID, entity.PersonPatch{Gender: &gender, Version: 0})
+		done <- err
+	}()
+
+	// The person has left the old tree but is not in the new one yet
+	<-girls.adding
+	got, err := h.GetPerson(context.Background(), p.ID)
+	assert.Nil(t, err, "a read should wait for the move")
+	assert.Equal(t, constant.GenderFemale, got.Gender)
+	assert.Nil(t, <-done)
+}
+
+func Test_ListPersons(t *testing.T) {
+	h := newTestHandler(t)
+
+	for _, p := range []entity.Person{
+		{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+		{Name: "b", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)},
+		{Name: "c", Height: 170, Gender: constant.GenderNonBinary, Seeking: []constant.Gender{constant.GenderMale}, WantedDates: cTypes.Uint64(1)},
+		{Name: "d", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+	} {
+		_, err := h.AddPerson(p)
+		assert.Nil(t, err)
+	}
+
+	tests := []struct {
+		name      string
+		q         PersonQuery
+		page      paging.Paginator
+		wantIDs   []uint64
+		wantTotal int
+	}{
+		{"All by height then ID", PersonQuery{}, paging.Paginator{Limit: paging.Unlimited}, []uint64{2, 3, 4, 1}, 4},
+		{"Paged", PersonQuery{}, paging.Paginator{Offset: 1, Limit: 2}, []uint64{3, 4}, 4},
+		{"Out of range", PersonQuery{}, paging.Paginator{Offset: 10, Limit: 2}, []uint64{}, 4},
+		{"Gender", PersonQuery{Gender: constant.GenderMale}, paging.Paginator{Limit: paging.Unlimited}, []uint64{4, 1}, 2},
+		{"Height range", PersonQuery{MinHeight: 165, MaxHeight: 175}, paging.Paginator{Limit: paging.Unlimited}, []uint64{3, 4}, 2},
+		{"Minimum height only", PersonQuery{MinHeight: 175}, paging.Paginator{Limit: paging.Unlimited}, []uint64{1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := h.ListPersons(context.Background(), tt.q, tt.page)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			ids := []uint64{}
+			for _, p := range got {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+
+	for _, q := range []PersonQuery{{Gender: "robot"}, {MinHeight: -1}, {MinHeight: 180, MaxHeight: 170}} {
+		_, _, err := h.ListPersons(context.Background(), q, paging.Paginator{Limit: paging.Unlimited})
+		assert.Equal(t, ErrorInvalidFilter, err)
+	}
+
+	p, err := h.GetPerson(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "c", p.Name)
+	_, err = h.GetPerson(context.Background(), 99)
+	assert.Equal(t, ErrorPersonNotFound, err)
+}
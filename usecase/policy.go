@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+var ErrorUnknownMatchPolicy = errors.New("unknown match policy")
+
+// NewMatchPolicy returns the policy registered under name, the default policy when name is empty
+func NewMatchPolicy(name string, heightTolerance float64) (MatchPolicy, error) {
+	switch name {
+	case "", constant.MatchPolicyTallerMale:
+		return TallerMalePolicy{}, nil
+	case constant.MatchPolicyHeightTolerance:
+		if heightTolerance < 0 {
+			return nil, errors.Errorf("negative height tolerance %v", heightTolerance)
+		}
+		return HeightTolerancePolicy{Tolerance: heightTolerance}, nil
+	}
+	return nil, errors.Wrap(ErrorUnknownMatchPolicy, name)
+}
+
+// TallerMalePolicy only matches a male with a female who is not taller than him,
+// pairs of any other genders have no height rule
+type TallerMalePolicy struct{}
+
+func (TallerMalePolicy) CandidateRange(p entity.Person, candidateGender constant.Gender) (minHeight, maxHeight float64) {
+	switch {
+	case p.Gender == constant.GenderMale && candidateGender == constant.GenderFemale:
+		return 0, p.Height
+	case p.Gender == constant.GenderFemale && candidateGender == constant.GenderMale:
+		return p.Height, math.MaxFloat64
+	}
+	return 0, math.MaxFloat64
+}
+
+func (TallerMalePolicy) Check(p1, p2 entity.Person) error {
+	if (p1.Gender == constant.GenderMale && p2.Gender == constant.GenderFemale && p1.Height < p2.Height) ||
+		(p1.Gender == constant.GenderFemale && p2.Gender == constant.GenderMale && p1.Height > p2.Height) {
+		return ErrorHeightCheckFailed
+	}
+	return nil
+}
+
+// HeightTolerancePolicy matches two people whose heights differ by at most Tolerance, whoever is taller
+type HeightTolerancePolicy struct {
+	Tolerance float64
+}
+
+func (hp HeightTolerancePolicy) CandidateRange(p entity.Person, _ constant.Gender) (minHeight, maxHeight float64) {
+	return math.Max(0, p.Height-hp.Tolerance), p.Height + hp.Tolerance
+}
+
+func (hp HeightTolerancePolicy) Check(p1, p2 entity.Person) error {
+	if math.Abs(p1.Height-p2.Height) > hp.Tolerance {
+		return ErrorHeightCheckFailed
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/tree"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_NewMatchPolicy(t *testing.T) {
+	policy, err := NewMatchPolicy("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, TallerMalePolicy{}, policy)
+
+	policy, err = NewMatchPolicy(constant.MatchPolicyHeightTolerance, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, HeightTolerancePolicy{Tolerance: 5}, policy)
+
+	_, err = NewMatchPolicy(constant.MatchPolicyHeightTolerance, -1)
+	assert.NotNil(t, err)
+
+	_, err = NewMatchPolicy("unknown", 0)
+	assert.ErrorIs(t, err, ErrorUnknownMatchPolicy)
+}
+
+func Test_MatchPolicy(t *testing.T) {
+	male := entity.Person{Height: 170, Gender: constant.GenderMale}
+	female := entity.Person{Height: 160, Gender: constant.GenderFemale}
+	tallFemale := entity.Person{Height: 175, Gender: constant.GenderFemale}
+	shortFemale := entity.Person{Height: 150, Gender: constant.GenderFemale}
+	tallMale := entity.Person{Height: 185, Gender: constant.GenderMale}
+	nonBinary := entity.Person{Height: 190, Gender: constant.GenderNonBinary}
+
+	tests := []struct {
+		name    string
+		policy  MatchPolicy
+		p1, p2  entity.Person
+		wantMin float64
+		wantMax float64
+		wantErr error
+	}{
+		{"Taller male", TallerMalePolicy{}, male, female, 0, 170, nil},
+		{"Taller male from female", TallerMalePolicy{}, female, male, 160, math.MaxFloat64, nil},
+		{"Taller female", TallerMalePolicy{}, male, tallFemale, 0, 170, ErrorHeightCheckFailed},
+		{"Same gender has no height rule", TallerMalePolicy{}, male, tallMale, 0, math.MaxFloat64, nil},
+		{"Non-binary has no height rule", TallerMalePolicy{}, female, nonBinary, 0, math.MaxFloat64, nil},
+		{"Within tolerance", HeightTolerancePolicy{Tolerance: 10}, male, tallFemale, 160, 180, nil},
+		{"Within tolerance from female", HeightTolerancePolicy{Tolerance: 10}, tallFemale, male, 165, 185, nil},
+		{"Out of tolerance", HeightTolerancePolicy{Tolerance: 10}, male, shortFemale, 160, 180, ErrorHeightCheckFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMin, gotMax := tt.policy.CandidateRange(tt.p1, tt.p2.Gender)
+			assert.Equal(t, tt.wantMin, gotMin)
+			assert.Equal(t, tt.wantMax, gotMax)
+			assert.Equal(t, tt.wantErr, tt.policy.Check(tt.p1, tt.p2))
+		})
+	}
+}
+
+func (s *personTestSuite) Test_QuerySinglePeopleWithPolicy() {
+	person := entity.Person{
+		ID:          1,
+		Name:        "a",
+		Height:      170,
+		Gender:      "male",
+		Seeking:     []constant.Gender{constant.GenderFemale},
+		WantedDates: cTypes.Uint64(1),
+	}
+	h := NewPersonHandler(s.trees(), s.matches, s.reactions, s.reports, WithMatchPolicy(HeightTolerancePolicy{Tolerance: 5}))
+
+	s.boys.EXPECT().FindByID(person.ID).Return(&person, true)
+	s.reactions.EXPECT().Passed(person.ID).Return(nil, nil)
+	s.reactions.EXPECT().Blocked(person.ID).Return(nil, nil)
+	s.girls.EXPECT().QueryNearest(170.0, gomock.Any(), 2).DoAndReturn(func(target float64, q tree.Query, k int) []entity.Person {
+		assert.Equal(s.T(), 165.0, q.MinHeight)
+		assert.Equal(s.T(), 175.0, q.MaxHeight)
+		return nil
+	})
+
+	_, err := h.QuerySinglePeople(context.Background(), person.ID, CandidateQuery{Num: 1, Sort: constant.SortNearest})
+	assert.Nil(s.T(), err)
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/wal"
+)
+
+// LikeResult tells whether a like completed a match
+type LikeResult struct {
+	Matched bool
+	Match   *entity.Match
+	// MatchErr is why a mutual like did not become a match, the like itself is kept
+	MatchErr error `json:"-"`
+}
+
+func (h *PersonHandler) Like(ctx context.Context, id, candidateID uint64) (LikeResult, error) {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	var result LikeResult
+	err := h.logged(wal.Record{Op: wal.OpLike, ID1: id, ID2: candidateID}, func(at time.Time) (err error) {
+		result, err = h.like(id, candidateID, at)
+		return err
+	})
+
+	return result, err
+}
+
+func (h *PersonHandler) like(id, candidateID uint64, at time.Time) (LikeResult, error) {
+	person, candidate, err := h.findReactionPair(id, candidateID)
+	if err != nil {
+		return LikeResult{}, err
+	}
+
+	// Refuse likes that could never become a match
+	if !person.CompatibleWith(*candidate) {
+		return LikeResult{}, ErrorGenderIncompatible
+	}
+	if err := h.policy.Check(*person, *candidate); err != nil {
+		return LikeResult{}, err
+	}
+	if err := h.checkNotMatched(id, candidateID); err != nil {
+		return LikeResult{}, err
+	}
+
+	mutual, err := h.putLike(entity.Reaction{
+		PersonID:    id,
+		CandidateID: candidateID,
+		Kind:        constant.ReactionLike,
+		CreatedAt:   at,
+	})
+	if err != nil {
+		return LikeResult{}, err
+	}
+	h.activity.touch(id, at)
+	if !mutual {
+		return LikeResult{}, nil
+	}
+
+	// The like is stored by now, so a failed match does not fail the like
+	match, err := h.match(id, candidateID, at, true)
+	if err != nil {
+		return LikeResult{MatchErr: err}, nil
+	}
+	return LikeResult{Matched: true, Match: &match}, nil
+}
+
+// putLike saves the like and reports whether the candidate already likes the person back.
+// Both happen under the pair lock, so only one of two crossing likes sees the other
+// and a like never replaces a block made in between.
+func (h *PersonHandler) putLike(r entity.Reaction) (mutual bool, err error) {
+	unlock := h.lockPair(r.PersonID, r.CandidateID)
+	defer unlock()
+
+	if err := h.checkNotBlocked(r.PersonID, r.CandidateID); err != nil {
+		return false, err
+	}
+	if err := h.reactions.Put(r); err != nil {
+		return false, errors.Wrap(err, "put reaction")
+	}
+	return h.likes(r.CandidateID, r.PersonID, r.CreatedAt)
+}
+
+func (h *PersonHandler) Pass(ctx context.Context, id, candidateID uint64) error {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	return h.logged(wal.Record{Op: wal.OpPass, ID1: id, ID2: candidateID}, func(at time.Time) error {
+		return h.pass(id, candidateID, at)
+	})
+}
+
+func (h *PersonHandler) pass(id, candidateID uint64, at time.Time) error {
+	if _, _, err := h.findReactionPair(id, candidateID); err != nil {
+		return err
+	}
+
+	unlock := h.lockPair(id, candidateID)
+	defer unlock()
+
+	if err := h.checkNotBlocked(id, candidateID); err != nil {
+		return err
+	}
+
+	// A pass replaces an earlier like, so it can no longer become a match
+	err := h.reactions.Put(entity.Reaction{
+		PersonID:    id,
+		CandidateID: candidateID,
+		Kind:        constant.ReactionPass,
+		CreatedAt:   at,
+	})
+	if err != nil {
+		return errors.Wrap(err, "put reaction")
+	}
+	h.activity.touch(id, at)
+
+	return nil
+}
+
+func (h *PersonHandler) Block(ctx context.Context, id, candidateID uint64) error {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	return h.logged(wal.Record{Op: wal.OpBlock, ID1: id, ID2: candidateID}, func(at time.Time) error {
+		return h.block(id, candidateID, at)
+	})
+}
+
+func (h *PersonHandler) block(id, candidateID uint64, at time.Time) error {
+	if _, _, err := h.findReactionPair(id, candidateID); err != nil {
+		return err
+	}
+
+	// Under the pair lock so a like, pass or match in progress finishes first
+	unlock := h.lockPair(id, candidateID)
+	defer unlock()
+
+	// A block replaces an earlier like or pass
+	err := h.reactions.Put(entity.Reaction{
+		PersonID:    id,
+		CandidateID: candidateID,
+		Kind:        constant.ReactionBlock,
+		CreatedAt:   at,
+	})
+	if err != nil {
+		return errors.Wrap(err, "put reaction")
+	}
+
+	return nil
+}
+
+func (h *PersonHandler) Unblock(ctx context.Context, id, candidateID uint64) error {
+	h.gate.RLock()
+	defer h.gate.RUnlock()
+
+	return h.logged(wal.Record{Op: wal.OpUnblock, ID1: id, ID2: candidateID}, func(time.Time) error {
+		return h.unblock(id, candidateID)
+	})
+}
+
+// unblock lifts the block of id on candidateID, it does nothing when there is none.
+// The candidate may have been removed since.
+func (h *PersonHandler) unblock(id, candidateID uint64) error {
+	unlock := h.lockPair(id, candidateID)
+	defer unlock()
+
+	r, exist, err := h.reactions.Find(id, candidateID)
+	if err != nil {
+		return errors.Wrap(err, "find reaction")
+	}
+	if !exist || r.Kind != constant.ReactionBlock {
+		return nil
+	}
+
+	if err := h.reactions.Delete(id, candidateID); err != nil {
+		return errors.Wrap(err, "delete reaction")
+	}
+	return nil
+}
+
+// checkNotBlocked returns ErrorBlocked when either person blocked the other
+func (h *PersonHandler) checkNotBlocked(id1, id2 uint64) error {
+	_, err := h.pairReactions(id1, id2)
+	return err
+}
+
+// pairReactions returns the reactions of the two persons on each other, or ErrorBlocked
+// when either person blocked the other
+func (h *PersonHandler) pairReactions(id1, id2 uint64) ([]entity.Reaction, error) {
+	var reactions []entity.Reaction
+	for _, pair := range [][2]uint64{{id1, id2}, {id2, id1}} {
+		r, exist, err := h.reactions.Find(pair[0], pair[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "find reaction")
+		}
+		if !exist {
+			continue
+		}
+		if r.Kind == constant.ReactionBlock {
+			return nil, ErrorBlocked
+		}
+		reactions = append(reactions, *r)
+	}
+	return reactions, nil
+}
+
+func (h *PersonHandler) findReactionPair(id, candidateID uint64) (person, candidate *entity.Person, err error) {
+	if id == candidateID {
+		return nil, nil, ErrorMatchSamePerson
+	}
+
+	if person, err = h.findPerson(id); err != nil {
+		return nil, nil, err
+	}
+	if candidate, err = h.findPerson(candidateID); err != nil {
+		return nil, nil, err
+	}
+	return person, candidate, nil
+}
+
+// likes reports whether personID has a like on candidateID that has not expired at at
+func (h *PersonHandler) likes(personID, candidateID uint64, at time.Time) (bool, error) {
+	r, exist, err := h.reactions.Find(personID, candidateID)
+	if err != nil {
+		return false, errors.Wrap(err, "find reaction")
+	}
+	if !exist || r.Kind != constant.ReactionLike {
+		return false, nil
+	}
+	return h.likeTTL <= 0 || at.Sub(r.CreatedAt) < h.likeTTL, nil
+}
+
+func (h *PersonHandler) checkMutualLike(id1, id2 uint64, at time.Time) error {
+	for _, pair := range [][2]uint64{{id1, id2}, {id2, id1}} {
+		liked, err := h.likes(pair[0], pair[1], at)
+		if err != nil {
+			return err
+		}
+		if !liked {
+			return ErrorNotMutualLike
+		}
+	}
+	return nil
+}
+
+func (h *PersonHandler) consumeLikes(id1, id2 uint64) error {
+	if err := h.reactions.Delete(id1, id2); err != nil {
+		return errors.Wrap(err, "delete reaction")
+	}
+	if err := h.reactions.Delete(id2, id1); err != nil {
+		return errors.Wrap(err, "delete reaction")
+	}
+	return nil
+}
+
+// hiddenSet returns the candidates personID passed on, the persons blocked either way
+// and, unless rematches are allowed, the former partners
+func (h *PersonHandler) hiddenSet(personID uint64) (map[uint64]bool, error) {
+	passed, err := h.reactions.Passed(personID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list passes")
+	}
+	blocked, err := h.reactions.Blocked(personID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list blocks")
+	}
+
+	var partners []uint64
+	if !h.allowRematch {
+		if partners, err = h.matches.Partners(personID); err != nil {
+			return nil, errors.Wrap(err, "list partners")
+		}
+	}
+
+	hidden := make(map[uint64]bool, len(passed)+len(blocked)+len(partners))
+	for _, ids := range [][]uint64{passed, blocked, partners} {
+		for _, id := range ids {
+			hidden[id] = true
+		}
+	}
+	return hidden, nil
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/matchstore"
+	"github.com/ars0915/matching-system/internal/reactionstore"
+	"github.com/ars0915/matching-system/internal/reportstore"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+// initCouple returns a handler using an in-memory reaction store with a
+// boy and a girl who can see each other
+func (s *personTestSuite) initCouple(optFn ...PersonHandlerOption) (*PersonHandler, []entity.Person) {
+	people := []entity.Person{
+		{ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, Seeking: []constant.Gender{constant.GenderFemale}, WantedDates: cTypes.Uint64(2)},
+		{ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, Seeking: []constant.Gender{constant.GenderMale}, WantedDates: cTypes.Uint64(2)},
+	}
+	s.boys.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		return &people[0], id == people[0].ID
+	}).AnyTimes()
+	s.girls.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		return &people[1], id == people[1].ID
+	}).AnyTimes()
+
+	return NewPersonHandler(s.trees(), s.matches, reactionstore.NewReactionStore(), reportstore.NewReportStore(), optFn...), people
+}
+
+func (s *personTestSuite) Test_LikeOneSide() {
+	h, _ := s.initCouple()
+
+	result, err := h.Like(context.Background(), 1, 2)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), result.Matched, "a like alone should not match")
+
+	_, err = h.Match(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorNotMutualLike, err)
+}
+
+func (s *personTestSuite) Test_LikeMutual() {
+	h, people := s.initCouple()
+
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil)
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil)
+
+	_, err := h.Like(context.Background(), 2, 1)
+	assert.Nil(s.T(), err)
+	result, err := h.Like(context.Background(), 1, 2)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), result.Matched, "liking back should match")
+	assert.Equal(s.T(), uint64(1), result.Match.PersonID1)
+	assert.Equal(s.T(), uint64(1), *people[0].WantedDates)
+
+	// Both likes were spent on the match
+	_, err = h.Match(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorNotMutualLike, err)
+}
+
+func (s *personTestSuite) Test_LikeExpired() {
+	h, _ := s.initCouple(WithLikeTTL(time.Hour))
+
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, err := h.like(2, 1, at)
+	assert.Nil(s.T(), err)
+
+	result, err := h.like(1, 2, at.Add(time.Hour))
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), result.Matched, "an expired like should not match")
+}
+
+func (s *personTestSuite) Test_LikeMatchFailed() {
+	h, people := s.initCouple()
+
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil).AnyTimes()
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil).AnyTimes()
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(errors.New("disk full"))
+
+	_, err := h.Like(context.Background(), 2, 1)
+	assert.Nil(s.T(), err)
+	result, err := h.Like(context.Background(), 1, 2)
+	assert.Nil(s.T(), err, "the like should be saved though the match failed")
+	assert.False(s.T(), result.Matched)
+	assert.NotNil(s.T(), result.MatchErr)
+	assert.Equal(s.T(), uint64(2), *people[0].WantedDates)
+
+	// Both likes are kept, so the match can be retried
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil)
+	_, err = h.Match(context.Background(), 1, 2)
+	assert.Nil(s.T(), err)
+}
+
+func (s *personTestSuite) Test_LikeHeightCheckFail() {
+	h, people := s.initCouple()
+	people[1].Height = 190
+
+	_, err := h.Like(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorHeightCheckFailed, err)
+}
+
+func (s *personTestSuite) Test_PassHidesCandidate() {
+	h, people := s.initCouple()
+	s.expectCandidates(s.girls, people[1])
+
+	page, err := h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 5})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Person{people[1]}, peopleOf(page.Candidates))
+
+	assert.Nil(s.T(), h.Pass(context.Background(), 1, 2))
+	page, err = h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 5})
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), peopleOf(page.Candidates), "passed candidate should be hidden")
+
+	// A like from the other side can no longer complete a match
+	result, err := h.Like(context.Background(), 2, 1)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), result.Matched)
+}
+
+func (s *personTestSuite) Test_LikeSelf() {
+	h, _ := s.initCouple()
+
+	_, err := h.Like(context.Background(), 1, 1)
+	assert.Equal(s.T(), ErrorMatchSamePerson, err)
+	assert.Equal(s.T(), ErrorMatchSamePerson, h.Pass(context.Background(), 1, 1))
+}
+
+func (s *personTestSuite) Test_PassPersonNotFound() {
+	h, _ := s.initCouple()
+	s.nonBinary.EXPECT().FindByID(uint64(3)).Return(nil, false)
+
+	assert.Equal(s.T(), ErrorPersonNotFound, h.Pass(context.Background(), 1, 3))
+}
+
+func (s *personTestSuite) Test_RematchRejected() {
+	h, people := s.initCouple()
+	h.matches = matchstore.NewMatchStore()
+	s.expectCandidates(s.girls, people[1])
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil)
+
+	_, err := h.match(1, 2, time.Now(), false)
+	assert.Nil(s.T(), err)
+
+	_, err = h.match(2, 1, time.Now(), false)
+	assert.Equal(s.T(), ErrorAlreadyMatched, err, "the order of the pair should not matter")
+	_, err = h.Like(context.Background(), 1, 2)
+	assert.Equal(s.T(), ErrorAlreadyMatched, err)
+	assert.Equal(s.T(), uint64(1), *people[0].WantedDates, "a rejected rematch should not use a date")
+
+	page, err := h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 5})
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), page.Candidates, "former partner should be hidden")
+}
+
+func (s *personTestSuite) Test_RematchAllowed() {
+	h, people := s.initCouple(WithRematch(true))
+	h.matches = matchstore.NewMatchStore()
+	s.expectCandidates(s.girls, people[1])
+	s.boys.EXPECT().UpdatePerson(&people[0]).Return(nil).Times(2)
+	s.girls.EXPECT().UpdatePerson(&people[1]).Return(nil).Times(2)
+	s.boys.EXPECT().RemovePerson(people[0].ID).Return(nil)
+	s.girls.EXPECT().RemovePerson(people[1].ID).Return(nil)
+
+	_, err := h.match(1, 2, time.Now(), false)
+	assert.Nil(s.T(), err)
+
+	page, err := h.QuerySinglePeople(context.Background(), 1, CandidateQuery{Num: 5})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Person{people[1]}, peopleOf(page.Candidates))
+
+	_, err = h.match(1, 2, time.Now(), false)
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(0), *people[0].WantedDates)
+}
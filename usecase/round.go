@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/wal"
+)
+
+// ScoreFunc rates candidate from the point of view of p, higher is better
+type ScoreFunc func(p, candidate entity.Person) float64
+
+// HeightScore prefers candidates of similar height
+func HeightScore(p, candidate entity.Person) float64 {
+	return -math.Abs(p.Height - candidate.Height)
+}
+
+// RoundPair is a male and a female paired by a matching round
+type RoundPair struct {
+	PersonID1 uint64
+	PersonID2 uint64
+	// Score is the sum of the scores both people give each other
+	Score float64
+}
+
+type RoundReport struct {
+	Committed bool
+	// Partial is set when some pairs of a committed round could not be matched,
+	// they are in Failed and the other pairs stay matched
+	Partial bool
+	Pairs   []RoundPair
+	// Matches are the matches created when the round is committed
+	Matches []entity.Match
+	Failed  []RoundPair
+}
+
+// RunRound pairs everyone in the male and female pools at once with a many-to-many
+// stable allocation, each person takes at most WantedDates partners. Non-binary
+// people and same-gender pairs are left to the like workflow, stable allocation
+// needs two sides. A dry run only reports the pairs.
+func (h *RoundHandler) RunRound(ctx context.Context, commit bool) (RoundReport, error) {
+	p := h.person
+
+	// Nobody may match or change while the round is planned and committed
+	p.gate.Lock()
+	defer p.gate.Unlock()
+
+	males, err := p.roundPool(constant.GenderMale)
+	if err != nil {
+		return RoundReport{}, err
+	}
+	females, err := p.roundPool(constant.GenderFemale)
+	if err != nil {
+		return RoundReport{}, err
+	}
+
+	hidden := map[uint64]map[uint64]bool{}
+	for _, person := range append(males, females...) {
+		if hidden[person.ID], err = p.hiddenSet(person.ID); err != nil {
+			return RoundReport{}, err
+		}
+	}
+	acceptable := func(m, f entity.Person) bool {
+		return m.CompatibleWith(f) && p.policy.Check(m, f) == nil && !hidden[m.ID][f.ID] && !hidden[f.ID][m.ID]
+	}
+
+	report := RoundReport{
+		Pairs: stableAllocation(males, females, acceptable, h.score),
+	}
+	if !commit {
+		return report, nil
+	}
+
+	// The round goes to the log as one batch, every pair is tried so that a
+	// replay matches the same pairs
+	records := make([]wal.Record, len(report.Pairs))
+	for i, pair := range report.Pairs {
+		records[i] = wal.Record{Op: wal.OpMatch, ID1: pair.PersonID1, ID2: pair.PersonID2}
+	}
+	err = p.loggedBatch(records, func(at time.Time) error {
+		for _, pair := range report.Pairs {
+			match, err := p.match(pair.PersonID1, pair.PersonID2, at, false)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"personID1": pair.PersonID1,
+					"personID2": pair.PersonID2,
+				}).WithError(err).Warn("Commit round pair failed")
+				report.Failed = append(report.Failed, pair)
+				continue
+			}
+			report.Matches = append(report.Matches, match)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Committed = true
+	report.Partial = len(report.Failed) > 0
+
+	return report, nil
+}
+
+// roundPool copies the persons of gender g who still want dates
+func (h *PersonHandler) roundPool(g constant.Gender) ([]entity.Person, error) {
+	t, err := h.treeOf(g)
+	if err != nil {
+		return nil, err
+	}
+
+	var pool []entity.Person
+	for _, person := range t.Snapshot() {
+		if *person.WantedDates > 0 {
+			pool = append(pool, person)
+		}
+	}
+	return pool, nil
+}
+
+// stableAllocation runs deferred acceptance with proposers proposing to
+// receivers in order of preference. A receiver holds the best proposals up to
+// its capacity and rejects the rest, so no acceptable pair left out prefers
+// each other to a partner they hold. Capacity is WantedDates on both sides.
+func stableAllocation(proposers, receivers []entity.Person, acceptable func(p, r entity.Person) bool, score ScoreFunc) []RoundPair {
+	type choice struct {
+		index int
+		score float64
+	}
+	byScore := func(choices []choice, ids func(int) uint64) {
+		// Ties go to the lower ID so the round is deterministic
+		sort.Slice(choices, func(i, j int) bool {
+			if choices[i].score != choices[j].score {
+				return choices[i].score > choices[j].score
+			}
+			return ids(choices[i].index) < ids(choices[j].index)
+		})
+	}
+
+	prefs := make([][]int, len(proposers))
+	rank := make([]map[int]int, len(receivers))
+	for r := range receivers {
+		rank[r] = map[int]int{}
+	}
+	for p := range proposers {
+		var choices []choice
+		for r := range receivers {
+			if acceptable(proposers[p], receivers[r]) {
+				choices = append(choices, choice{r, score(proposers[p], receivers[r])})
+			}
+		}
+		byScore(choices, func(i int) uint64 { return receivers[i].ID })
+		for _, c := range choices {
+			prefs[p] = append(prefs[p], c.index)
+		}
+	}
+	for r := range receivers {
+		var choices []choice
+		for p := range proposers {
+			if acceptable(proposers[p], receivers[r]) {
+				choices = append(choices, choice{p, score(receivers[r], proposers[p])})
+			}
+		}
+		byScore(choices, func(i int) uint64 { return proposers[i].ID })
+		for i, c := range choices {
+			rank[r][c.index] = i
+		}
+	}
+
+	var (
+		next  = make([]int, len(proposers))
+		held  = make([]int, len(proposers))
+		holds = make([][]int, len(receivers))
+		queue []int
+	)
+	for p := range proposers {
+		queue = append(queue, p)
+	}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		for held[p] < int(*proposers[p].WantedDates) && next[p] < len(prefs[p]) {
+			r := prefs[p][next[p]]
+			next[p]++
+
+			holds[r] = append(holds[r], p)
+			held[p]++
+			if len(holds[r]) <= int(*receivers[r].WantedDates) {
+				continue
+			}
+
+			// Over capacity, reject the proposer the receiver likes least
+			worst := 0
+			for i, q := range holds[r] {
+				if rank[r][q] > rank[r][holds[r][worst]] {
+					worst = i
+				}
+			}
+			rejected := holds[r][worst]
+			holds[r] = append(holds[r][:worst], holds[r][worst+1:]...)
+			held[rejected]--
+			if rejected != p {
+				queue = append(queue, rejected)
+			}
+		}
+	}
+
+	var pairs []RoundPair
+	for r, ps := range holds {
+		for _, p := range ps {
+			pairs = append(pairs, RoundPair{
+				PersonID1: proposers[p].ID,
+				PersonID2: receivers[r].ID,
+				Score:     score(proposers[p], receivers[r]) + score(receivers[r], proposers[p]),
+			})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].PersonID1 != pairs[j].PersonID1 {
+			return pairs[i].PersonID1 < pairs[j].PersonID1
+		}
+		return pairs[i].PersonID2 < pairs[j].PersonID2
+	})
+	return pairs
+}
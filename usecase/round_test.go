@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/wal"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_stableAllocation(t *testing.T) {
+	person := func(id, dates uint64) entity.Person {
+		return entity.Person{ID: id, WantedDates: cTypes.Uint64(dates)}
+	}
+	// scores[a][b] is how much a likes b
+	scoreOf := func(scores map[uint64]map[uint64]float64) ScoreFunc {
+		return func(p, candidate entity.Person) float64 { return scores[p.ID][candidate.ID] }
+	}
+	all := func(p, r entity.Person) bool { return true }
+
+	tests := []struct {
+		name       string
+		proposers  []entity.Person
+		receivers  []entity.Person
+		acceptable func(p, r entity.Person) bool
+		scores     map[uint64]map[uint64]float64
+		want       [][2]uint64
+	}{
+		{
+			name:       "Receivers choose",
+			proposers:  []entity.Person{person(1, 1), person(2, 1)},
+			receivers:  []entity.Person{person(11, 1), person(12, 1)},
+			acceptable: all,
+			// Both proposers prefer 11, who prefers 2
+			scores: map[uint64]map[uint64]float64{
+				1: {11: 2, 12: 1}, 2: {11: 2, 12: 1},
+				11: {1: 1, 2: 2}, 12: {1: 1, 2: 2},
+			},
+			want: [][2]uint64{{1, 12}, {2, 11}},
+		},
+		{
+			name:       "Capacity",
+			proposers:  []entity.Person{person(1, 2), person(2, 1), person(3, 1)},
+			receivers:  []entity.Person{person(11, 2), person(12, 1)},
+			acceptable: all,
+			scores: map[uint64]map[uint64]float64{
+				1: {11: 2, 12: 1}, 2: {11: 2, 12: 1}, 3: {11: 2, 12: 1},
+				11: {1: 3, 2: 2, 3: 1}, 12: {1: 3, 2: 2, 3: 1},
+			},
+			want: [][2]uint64{{1, 11}, {1, 12}, {2, 11}},
+		},
+		{
+			name:       "Unacceptable pair",
+			proposers:  []entity.Person{person(1, 1)},
+			receivers:  []entity.Person{person(11, 1), person(12, 1)},
+			acceptable: func(p, r entity.Person) bool { return r.ID != 11 },
+			scores:     map[uint64]map[uint64]float64{1: {11: 2, 12: 1}},
+			want:       [][2]uint64{{1, 12}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]uint64
+			for _, pair := range stableAllocation(tt.proposers, tt.receivers, tt.acceptable, scoreOf(tt.scores)) {
+				got = append(got, [2]uint64{pair.PersonID1, pair.PersonID2})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_stableAllocationIsStable(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		var proposers, receivers []entity.Person
+		for i := 0; i < 8; i++ {
+			proposers = append(proposers, entity.Person{ID: uint64(i + 1), Height: float64(150 + rnd.Intn(40)), WantedDates: cTypes.Uint64(uint64(1 + rnd.Intn(3)))})
+			receivers = append(receivers, entity.Person{ID: uint64(i + 101), Height: float64(150 + rnd.Intn(40)), WantedDates: cTypes.Uint64(uint64(1 + rnd.Intn(3)))})
+		}
+		acceptable := func(p, r entity.Person) bool { return p.Height >= r.Height }
+		pairs := stableAllocation(proposers, receivers, acceptable, HeightScore)
+
+		partners := map[uint64][]uint64{}
+		for _, pair := range pairs {
+			partners[pair.PersonID1] = append(partners[pair.PersonID1], pair.PersonID2)
+			partners[pair.PersonID2] = append(partners[pair.PersonID2], pair.PersonID1)
+		}
+		people := map[uint64]entity.Person{}
+		for _, p := range append(proposers, receivers...) {
+			people[p.ID] = p
+			assert.LessOrEqual(t, uint64(len(partners[p.ID])), *p.WantedDates, "capacity of %d", p.ID)
+		}
+
+		// wants reports whether a would take b, having room or preferring b to a partner
+		wants := func(a, b entity.Person) bool {
+			if uint64(len(partners[a.ID])) < *a.WantedDates {
+				return true
+			}
+			for _, id := range partners[a.ID] {
+				if HeightScore(a, b) > HeightScore(a, people[id]) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, p := range proposers {
+			for _, r := range receivers {
+				if !acceptable(p, r) || contains(partners[p.ID], r.ID) {
+					continue
+				}
+				assert.False(t, wants(p, r) && wants(r, p), "round %d: %d and %d block the allocation", round, p.ID, r.ID)
+			}
+		}
+	}
+}
+
+func contains(ids []uint64, id uint64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *personTestSuite) Test_RunRound() {
+	seekingFemale := []constant.Gender{constant.GenderFemale}
+	seekingMale := []constant.Gender{constant.GenderMale}
+	boys := []entity.Person{
+		{ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, Seeking: seekingFemale, WantedDates: cTypes.Uint64(1)},
+		{ID: 2, Name: "b", Height: 170, Gender: constant.GenderMale, Seeking: seekingFemale, WantedDates: cTypes.Uint64(1)},
+	}
+	girls := []entity.Person{
+		{ID: 3, Name: "c", Height: 160, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+		{ID: 4, Name: "d", Height: 175, Gender: constant.GenderFemale, Seeking: seekingMale, WantedDates: cTypes.Uint64(1)},
+	}
+	h := NewRoundHandler(s.h)
+
+	s.boys.EXPECT().Snapshot().Return(boys).Times(2)
+	s.girls.EXPECT().Snapshot().Return(girls).Times(2)
+	s.reactions.EXPECT().Passed(gomock.Any()).Return(nil, nil).Times(8)
+	s.reactions.EXPECT().Blocked(gomock.Any()).Return(nil, nil).Times(8)
+
+	// Girl 4 is taller than boy 2, so she can only pair with boy 1
+	report, err := h.RunRound(context.Background(), false)
+	assert.Nil(s.T(), err)
+	assert.False(s.T(), report.Committed)
+	assert.Equal(s.T(), []RoundPair{
+		{PersonID1: 1, PersonID2: 4, Score: -10},
+		{PersonID1: 2, PersonID2: 3, Score: -20},
+	}, report.Pairs)
+	assert.Empty(s.T(), report.Matches, "a dry run should not match")
+
+	people := map[uint64]*entity.Person{}
+	for i := range boys {
+		people[boys[i].ID] = &boys[i]
+		people[girls[i].ID] = &girls[i]
+	}
+	s.boys.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		p, exist := people[id]
+		return p, exist && p.Gender == constant.GenderMale
+	}).AnyTimes()
+	s.girls.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		p, exist := people[id]
+		return p, exist && p.Gender == constant.GenderFemale
+	}).AnyTimes()
+	s.boys.EXPECT().UpdatePerson(gomock.Any()).Return(nil).Times(2)
+	s.girls.EXPECT().UpdatePerson(gomock.Any()).Return(nil).Times(2)
+	s.boys.EXPECT().RemovePerson(gomock.Any()).Return(nil).Times(2)
+	s.girls.EXPECT().RemovePerson(gomock.Any()).Return(nil).Times(2)
+	s.reactions.EXPECT().Find(gomock.Any(), gomock.Any()).Return(nil, false, nil).Times(4)
+	s.reactions.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).Times(4)
+	s.matches.EXPECT().AddMatch(gomock.Any()).Return(nil).Times(2)
+
+	report, err = h.RunRound(context.Background(), true)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), report.Committed)
+	assert.Len(s.T(), report.Matches, 2)
+	for _, p := range people {
+		assert.Equal(s.T(), uint64(0), *p.WantedDates)
+	}
+}
+
+func (s *personTestSuite) Test_RunRoundPartialCommit() {
+	dir := s.T().TempDir()
+	p, l := recoverHandler(s.T(), dir, withMatchStore(s.matches))
+	for _, person := range []entity.Person{
+		{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+		{Name: "b", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)},
+		{Name: "c", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)},
+		{Name: "d", Height: 175, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)},
+	} {
+		_, err := p.AddPerson(person)
+		assert.Nil(s.T(), err)
+	}
+
+	s.matches.EXPECT().Partners(gomock.Any()).Return(nil, nil).AnyTimes()
+	s.matches.EXPECT().Matched(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	s.matches.EXPECT().AddMatch(gomock.Any()).DoAndReturn(func(m *entity.Match) error {
+		if m.PersonID1 == 2 {
+			return errors.New("disk full")
+		}
+		m.ID = 1
+		return nil
+	}).Times(2)
+
+	// The pair that fails is reported, the other one stays matched
+	report, err := NewRoundHandler(p).RunRound(context.Background(), true)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), report.Committed)
+	assert.True(s.T(), report.Partial)
+	if assert.Len(s.T(), report.Matches, 1) {
+		assert.Equal(s.T(), uint64(1), report.Matches[0].PersonID1)
+	}
+	assert.Equal(s.T(), []RoundPair{{PersonID1: 2, PersonID2: 3, Score: -20}}, report.Failed)
+	for _, id := range []uint64{1, 4} {
+		_, err := p.findPerson(id)
+		assert.Equal(s.T(), ErrorPersonNotFound, err, "matched person %d should be out of dates", id)
+	}
+	for _, id := range []uint64{2, 3} {
+		person, err := p.findPerson(id)
+		if assert.Nil(s.T(), err, "person %d of the failed pair should be kept", id) {
+			assert.Equal(s.T(), uint64(1), *person.WantedDates)
+		}
+	}
+
+	// The whole round is one record in the log
+	assert.Nil(s.T(), l.Close())
+	_, pending, err := wal.Open(filepath.Join(dir, "matching.wal"), 0)
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), pending, 5)
+	assert.Equal(s.T(), wal.OpBatch, pending[4].Op)
+	assert.Len(s.T(), pending[4].Batch, 2)
+}
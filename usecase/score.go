@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+var ErrorUnknownScorer = errors.New("unknown scorer")
+
+// ScoreInput is what a Scorer knows about a person and a candidate
+type ScoreInput struct {
+	Person    entity.Person
+	Candidate entity.Person
+	// CandidateActiveAt is when the candidate last joined, liked or passed, zero when unknown
+	CandidateActiveAt time.Time
+	Now               time.Time
+}
+
+// Scorer rates one factor of how well a candidate suits a person
+type Scorer interface {
+	// Score returns a score from 0 to 1, ok is false when the factor cannot be judged
+	// for the pair, e.g. one of them has no location
+	Score(in ScoreInput) (score float64, ok bool)
+}
+
+var scorers = map[string]Scorer{}
+
+// RegisterScorer makes s available to NewScoring under name and panics on a duplicated name
+func RegisterScorer(name string, s Scorer) {
+	if _, ok := scorers[name]; ok {
+		panic(fmt.Sprintf("scorer %q registered twice", name))
+	}
+	scorers[name] = s
+}
+
+func init() {
+	RegisterScorer(constant.ScorerHeightGap, HeightGapScorer{Scale: 30})
+	RegisterScorer(constant.ScorerSharedInterests, SharedInterestsScorer{})
+	RegisterScorer(constant.ScorerDistance, DistanceScorer{ScaleKm: 100})
+	RegisterScorer(constant.ScorerActivity, ActivityScorer{HalfLife: 72 * time.Hour})
+}
+
+// ScoreFactor is the part one scorer played in a candidate's score
+type ScoreFactor struct {
+	Name   string
+	Score  float64
+	Weight float64
+}
+
+// Candidate is a suggested person with why it was suggested
+type Candidate struct {
+	entity.Person
+	// Score is the weighted mean of the factors in Breakdown, from 0 to 1
+	Score     float64
+	Breakdown []ScoreFactor
+}
+
+type weightedScorer struct {
+	name   string
+	scorer Scorer
+	weight float64
+}
+
+// Scoring combines weighted scorers into one score
+type Scoring []weightedScorer
+
+// NewScoring looks up the scorer of every name in weights, scorers weighted 0 are
+// left out. Every registered scorer is weighted 1 when weights is empty.
+func NewScoring(weights map[string]float64) (Scoring, error) {
+	if len(weights) == 0 {
+		weights = map[string]float64{}
+		for name := range scorers {
+			weights[name] = 1
+		}
+	}
+
+	var s Scoring
+	for name, weight := range weights {
+		scorer, ok := scorers[name]
+		if !ok {
+			return nil, errors.Wrap(ErrorUnknownScorer, name)
+		}
+		if weight < 0 {
+			return nil, errors.Errorf("negative weight %v for scorer %s", weight, name)
+		}
+		if weight > 0 {
+			s = append(s, weightedScorer{name: name, scorer: scorer, weight: weight})
+		}
+	}
+	// Keep the breakdown in a stable order
+	sort.Slice(s, func(i, j int) bool { return s[i].name < s[j].name })
+
+	return s, nil
+}
+
+// score returns the weighted mean of the factors that apply to the pair
+func (s Scoring) score(in ScoreInput) (float64, []ScoreFactor) {
+	var (
+		total, weights float64
+		breakdown      []ScoreFactor
+	)
+	for _, ws := range s {
+		score, ok := ws.scorer.Score(in)
+		if !ok {
+			continue
+		}
+		total += score * ws.weight
+		weights += ws.weight
+		breakdown = append(breakdown, ScoreFactor{Name: ws.name, Score: score, Weight: ws.weight})
+	}
+
+	if weights == 0 {
+		return 0, breakdown
+	}
+	return total / weights, breakdown
+}
+
+// HeightGapScorer prefers similar heights, a gap of Scale cm or more scores 0
+type HeightGapScorer struct {
+	Scale float64
+}
+
+func (hs HeightGapScorer) Score(in ScoreInput) (float64, bool) {
+	gap := math.Abs(in.Person.Height - in.Candidate.Height)
+	return 1 - math.Min(gap, hs.Scale)/hs.Scale, true
+}
+
+// SharedInterestsScorer is the Jaccard index of both tag sets
+type SharedInterestsScorer struct{}
+
+func (SharedInterestsScorer) Score(in ScoreInput) (float64, bool) {
+	if len(in.Person.Tags) == 0 || len(in.Candidate.Tags) == 0 {
+		return 0, false
+	}
+
+	union := map[string]bool{}
+	for _, tag := range in.Person.Tags {
+		union[tag] = true
+	}
+	shared := 0
+	for _, tag := range in.Candidate.Tags {
+		if union[tag] {
+			shared++
+		}
+		union[tag] = true
+	}
+	return float64(shared) / float64(len(union)), true
+}
+
+// DistanceScorer prefers candidates nearby, ScaleKm away or more scores 0
+type DistanceScorer struct {
+	ScaleKm float64
+}
+
+func (ds DistanceScorer) Score(in ScoreInput) (float64, bool) {
+	if in.Person.Location == nil || in.Candidate.Location == nil {
+		return 0, false
+	}
+	d := in.Person.Location.DistanceKm(*in.Candidate.Location)
+	return 1 - math.Min(d, ds.ScaleKm)/ds.ScaleKm, true
+}
+
+// ActivityScorer prefers recently active candidates, the score halves every HalfLife
+type ActivityScorer struct {
+	HalfLife time.Duration
+}
+
+func (as ActivityScorer) Score(in ScoreInput) (float64, bool) {
+	if in.CandidateActiveAt.IsZero() {
+		return 0, false
+	}
+	idle := math.Max(0, float64(in.Now.Sub(in.CandidateActiveAt)))
+	return math.Pow(0.5, idle/float64(as.HalfLife)), true
+}
+
+// activityLog remembers when each person last joined, liked or passed. It is
+// saved in snapshots and rebuilt by replaying the write-ahead log after them.
+type activityLog struct {
+	mu sync.RWMutex
+	at map[uint64]time.Time
+}
+
+func newActivityLog() *activityLog {
+	return &activityLog{at: map[uint64]time.Time{}}
+}
+
+func (a *activityLog) touch(id uint64, at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if at.After(a.at[id]) {
+		a.at[id] = at
+	}
+}
+
+func (a *activityLog) last(id uint64) time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.at[id]
+}
+
+// all copies the activity of every person
+func (a *activityLog) all() map[uint64]time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make(map[uint64]time.Time, len(a.at))
+	for id, at := range a.at {
+		result[id] = at
+	}
+	return result
+}
+
+func (a *activityLog) forget(id uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.at, id)
+}
+
+// scoreCandidates attaches the score of every candidate for p
+func (h *PersonHandler) scoreCandidates(p entity.Person, people []entity.Person) []Candidate {
+	now := time.Now()
+	candidates := make([]Candidate, len(people))
+	for i, candidate := range people {
+		score, breakdown := h.scoring.score(ScoreInput{
+			Person:            p,
+			Candidate:         candidate,
+			CandidateActiveAt: h.activity.last(candidate.ID),
+			Now:               now,
+		})
+		candidates[i] = Candidate{Person: candidate, Score: score, Breakdown: breakdown}
+	}
+	return candidates
+}
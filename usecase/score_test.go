@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+)
+
+func Test_NewScoring(t *testing.T) {
+	scoring, err := NewScoring(nil)
+	assert.Nil(t, err)
+	assert.Len(t, scoring, len(scorers), "every scorer should be used by default")
+
+	scoring, err = NewScoring(map[string]float64{constant.ScorerHeightGap: 2, constant.ScorerDistance: 0})
+	assert.Nil(t, err)
+	if assert.Len(t, scoring, 1, "a scorer weighted 0 should be left out") {
+		assert.Equal(t, constant.ScorerHeightGap, scoring[0].name)
+	}
+
+	_, err = NewScoring(map[string]float64{constant.ScorerHeightGap: -1})
+	assert.NotNil(t, err)
+
+	_, err = NewScoring(map[string]float64{"unknown": 1})
+	assert.ErrorIs(t, err, ErrorUnknownScorer)
+}
+
+func Test_Scorers(t *testing.T) {
+	now := time.Now()
+	taipei := &entity.Location{Latitude: 25.033, Longitude: 121.565}
+	tainan := &entity.Location{Latitude: 22.999, Longitude: 120.227}
+
+	tests := []struct {
+		name   string
+		scorer Scorer
+		in     ScoreInput
+		want   float64
+		wantOk bool
+	}{
+		{"Same height", HeightGapScorer{Scale: 30}, ScoreInput{Person: entity.Person{Height: 170}, Candidate: entity.Person{Height: 170}}, 1, true},
+		{"Height gap", HeightGapScorer{Scale: 30}, ScoreInput{Person: entity.Person{Height: 170}, Candidate: entity.Person{Height: 155}}, 0.5, true},
+		{"Height gap beyond scale", HeightGapScorer{Scale: 30}, ScoreInput{Person: entity.Person{Height: 190}, Candidate: entity.Person{Height: 150}}, 0, true},
+		{"Shared interests", SharedInterestsScorer{}, ScoreInput{Person: entity.Person{Tags: []string{"jazz", "chess"}}, Candidate: entity.Person{Tags: []string{"jazz", "films"}}}, 1.0 / 3, true},
+		{"No interests", SharedInterestsScorer{}, ScoreInput{Person: entity.Person{Tags: []string{"jazz"}}}, 0, false},
+		{"Same place", DistanceScorer{ScaleKm: 100}, ScoreInput{Person: entity.Person{Location: taipei}, Candidate: entity.Person{Location: taipei}}, 1, true},
+		{"Far away", DistanceScorer{ScaleKm: 100}, ScoreInput{Person: entity.Person{Location: taipei}, Candidate: entity.Person{Location: tainan}}, 0, true},
+		{"No location", DistanceScorer{ScaleKm: 100}, ScoreInput{Person: entity.Person{Location: taipei}}, 0, false},
+		{"Just active", ActivityScorer{HalfLife: time.Hour}, ScoreInput{CandidateActiveAt: now, Now: now}, 1, true},
+		{"Active a half-life ago", ActivityScorer{HalfLife: time.Hour}, ScoreInput{CandidateActiveAt: now.Add(-time.Hour), Now: now}, 0.5, true},
+		{"Unknown activity", ActivityScorer{HalfLife: time.Hour}, ScoreInput{Now: now}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := tt.scorer.Score(tt.in)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.InDelta(t, tt.want, score, 1e-9)
+		})
+	}
+}
+
+func Test_ScoringWeightedMean(t *testing.T) {
+	scoring, err := NewScoring(map[string]float64{
+		constant.ScorerHeightGap:       1,
+		constant.ScorerSharedInterests: 3,
+		constant.ScorerDistance:        1,
+	})
+	assert.Nil(t, err)
+
+	score, breakdown := scoring.score(ScoreInput{
+		Person:    entity.Person{Height: 170, Tags: []string{"jazz"}},
+		Candidate: entity.Person{Height: 155, Tags: []string{"jazz"}},
+	})
+	assert.Equal(t, []ScoreFactor{
+		{Name: constant.ScorerHeightGap, Score: 0.5, Weight: 1},
+		{Name: constant.ScorerSharedInterests, Score: 1, Weight: 3},
+	}, breakdown, "distance should be left out without locations")
+	assert.InDelta(t, (0.5+3)/4, score, 1e-9)
+}
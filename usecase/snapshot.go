@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/snapshot"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func (h *SnapshotHandler) SaveSnapshot(ctx context.Context) (snapshot.Info, error) {
+	if h.path == "" {
+		return snapshot.Info{}, ErrorSnapshotDisabled
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, err := h.person.snapshot()
+	if err != nil {
+		return snapshot.Info{}, err
+	}
+	if err := snapshot.Save(h.path, s); err != nil {
+		return snapshot.Info{}, errors.Wrap(err, "save snapshot")
+	}
+
+	// Records up to the snapshot are no longer needed for recovery
+	if h.person.wal != nil {
+		if err := h.person.wal.Compact(s.WALSeq); err != nil {
+			return snapshot.Info{}, errors.Wrap(err, "compact wal")
+		}
+	}
+
+	s.Version = snapshot.Version
+	return s.Info(h.path), nil
+}
+
+// snapshot copies every tree, the matches, the reactions, the reports, the activity and the ID
+// counter while no mutation is in progress
+func (h *PersonHandler) snapshot() (snapshot.Snapshot, error) {
+	h.gate.Lock()
+	defer h.gate.Unlock()
+
+	s := snapshot.Snapshot{
+		TakenAt:      time.Now(),
+		LastPersonID: atomic.LoadUint64(h.id),
+	}
+	for _, g := range constant.Genders {
+		if t, exist := h.trees[g]; exist {
+			s.Persons = append(s.Persons, t.Snapshot()...)
+			for _, p := range t.Suspended() {
+				s.Persons = append(s.Persons, p)
+				s.Suspended = append(s.Suspended, p.ID)
+			}
+		}
+	}
+
+	matches, _, err := h.matches.List(0, paging.Unlimited)
+	if err != nil {
+		return snapshot.Snapshot{}, errors.Wrap(err, "list matches")
+	}
+	s.Matches = matches
+
+	reactions, err := h.reactions.List()
+	if err != nil {
+		return snapshot.Snapshot{}, errors.Wrap(err, "list reactions")
+	}
+	if s.Reactions, err = h.purgeExpiredLikes(reactions, s.TakenAt); err != nil {
+		return snapshot.Snapshot{}, err
+	}
+
+	reports, _, err := h.reports.List("", 0, paging.Unlimited)
+	if err != nil {
+		return snapshot.Snapshot{}, errors.Wrap(err, "list reports")
+	}
+	s.Reports = reports
+	s.Activity = h.activity.all()
+
+	if h.wal != nil {
+		s.WALSeq = h.wal.Seq()
+	}
+	return s, nil
+}
+
+// purgeExpiredLikes deletes the likes that can no longer become a match and returns
+// the other reactions, so expired likes do not pile up in the store and the snapshot
+func (h *PersonHandler) purgeExpiredLikes(reactions []entity.Reaction, now time.Time) ([]entity.Reaction, error) {
+	if h.likeTTL <= 0 {
+		return reactions, nil
+	}
+
+	kept := reactions[:0]
+	for _, r := range reactions {
+		if r.Kind == constant.ReactionLike && now.Sub(r.CreatedAt) >= h.likeTTL {
+			if err := h.reactions.Delete(r.PersonID, r.CandidateID); err != nil {
+				return nil, errors.Wrap(err, "delete expired like")
+			}
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept, nil
+}
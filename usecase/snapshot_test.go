@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/snapshot"
+	"github.com/ars0915/matching-system/internal/wal"
+	"github.com/ars0915/matching-system/util/cTypes"
+	"github.com/ars0915/matching-system/util/paging"
+)
+
+func (s *personTestSuite) Test_SaveSnapshot() {
+	boys := []entity.Person{
+		{ID: 1, Name: "a", Height: 180, Gender: "male", WantedDates: cTypes.Uint64(2)},
+	}
+	girls := []entity.Person{
+		{ID: 2, Name: "b", Height: 160, Gender: "female", WantedDates: cTypes.Uint64(1)},
+	}
+	s.initPeople(append(boys, girls...))
+
+	s.boys.EXPECT().Snapshot().Return(boys)
+	s.girls.EXPECT().Snapshot().Return(girls)
+	s.nonBinary.EXPECT().Snapshot().Return(nil)
+	s.boys.EXPECT().Suspended().Return(nil)
+	s.girls.EXPECT().Suspended().Return(nil)
+	s.nonBinary.EXPECT().Suspended().Return(nil)
+	s.matches.EXPECT().List(0, paging.Unlimited).Return(nil, 0, nil)
+	s.reactions.EXPECT().List().Return(nil, nil)
+	s.reports.EXPECT().List(constant.ReportStatus(""), 0, paging.Unlimited).Return(nil, 0, nil)
+
+	path := filepath.Join(s.T().TempDir(), "snapshot.json")
+	info, err := NewSnapshotHandler(s.h, path).SaveSnapshot(context.Background())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), path, info.Path)
+	assert.Equal(s.T(), uint64(2), info.LastPersonID)
+	assert.Equal(s.T(), 2, info.PersonCount)
+
+	got, exist, err := snapshot.Load(path)
+	assert.Nil(s.T(), err)
+	assert.True(s.T(), exist, "snapshot should be saved")
+	assert.Equal(s.T(), uint64(2), got.LastPersonID)
+	assert.Equal(s.T(), append(boys, girls...), got.Persons)
+}
+
+func (s *personTestSuite) Test_SaveSnapshotDisabled() {
+	_, err := NewSnapshotHandler(s.h, "").SaveSnapshot(context.Background())
+	assert.Equal(s.T(), ErrorSnapshotDisabled, err)
+}
+
+// recoverHandler recovers from the snapshot and the wal in dir the way the server does on boot,
+// opts apply before the snapshot is restored
+func recoverHandler(t *testing.T, dir string, opts ...PersonHandlerOption) (*PersonHandler, *wal.Log) {
+	t.Helper()
+	snap, exist, err := snapshot.Load(filepath.Join(dir, "snapshot.json"))
+	assert.Nil(t, err)
+	if exist {
+		restore := func(h *PersonHandler) {
+			assert.Nil(t, snap.Restore(h.trees, h.matches, h.reactions, h.reports))
+		}
+		opts = append(opts, restore, WithLastPersonID(snap.LastPersonID), WithActivity(snap.Activity))
+	}
+	l, pending, err := wal.Open(filepath.Join(dir, "matching.wal"), snap.WALSeq)
+	assert.Nil(t, err)
+	return newTestHandler(t, append(opts, WithWAL(l, pending))...), l
+}
+
+func Test_SnapshotRestart(t *testing.T) {
+	dir := t.TempDir()
+	h, l := recoverHandler(t, dir)
+	ctx := context.Background()
+
+	for _, p := range []entity.Person{
+		{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(3)},
+		{Name: "b", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(2)},
+		{Name: "c", Height: 165, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(2)},
+	} {
+		_, err := h.AddPerson(p)
+		assert.Nil(t, err)
+	}
+	_, err := h.Like(ctx, 1, 2)
+	assert.Nil(t, err)
+	result, err := h.Like(ctx, 2, 1)
+	assert.Nil(t, err)
+	assert.True(t, result.Matched)
+
+	wantMatches, _, err := NewMatchHandler(h.matches).ListMatches(ctx, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(t, err)
+	wantActive := h.activity.all()
+
+	// The snapshot compacts the wal, so it alone has to carry the matches and the activity
+	info, err := NewSnapshotHandler(h, filepath.Join(dir, "snapshot.json")).SaveSnapshot(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, info.MatchCount)
+	l.Close()
+
+	h, l = recoverHandler(t, dir)
+	defer l.Close()
+
+	gotMatches, _, err := NewMatchHandler(h.matches).ListPersonMatches(ctx, 1, paging.Paginator{Limit: paging.Unlimited})
+	assert.Nil(t, err)
+	assert.Equal(t, utcMatches(wantMatches), utcMatches(gotMatches))
+	partners, err := h.matches.Partners(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1}, partners)
+	assert.Equal(t, utcTimes(wantActive), utcTimes(h.activity.all()))
+
+	// Match IDs carry on from the restored ones
+	_, err = h.Like(ctx, 1, 3)
+	assert.Nil(t, err)
+	result, err = h.Like(ctx, 3, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), result.Match.ID)
+}
+
+func Test_RematchRejectedAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	h, l := recoverHandler(t, dir)
+	ctx := context.Background()
+
+	for _, p := range []entity.Person{
+		{Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(2)},
+		{Name: "b", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(2)},
+	} {
+		_, err := h.AddPerson(p)
+		assert.Nil(t, err)
+	}
+	_, err := h.Like(ctx, 1, 2)
+	assert.Nil(t, err)
+	result, err := h.Like(ctx, 2, 1)
+	assert.Nil(t, err)
+	assert.True(t, result.Matched)
+
+	_, err = NewSnapshotHandler(h, filepath.Join(dir, "snapshot.json")).SaveSnapshot(ctx)
+	assert.Nil(t, err)
+	l.Close()
+
+	h, l = recoverHandler(t, dir)
+	defer l.Close()
+
+	_, err = h.Like(ctx, 1, 2)
+	assert.Equal(t, ErrorAlreadyMatched, err, "the match in the snapshot should still forbid a rematch")
+	_, err = h.Match(ctx, 2, 1)
+	assert.Equal(t, ErrorAlreadyMatched, err)
+	page, err := h.QuerySinglePeople(ctx, 1, CandidateQuery{Num: 5})
+	assert.Nil(t, err)
+	assert.Empty(t, page.Candidates, "former partner should stay hidden")
+}
+
+func Test_SnapshotPurgesExpiredLikes(t *testing.T) {
+	h := newTestHandler(t, WithLikeTTL(time.Hour))
+
+	now := time.Now()
+	expired := entity.Reaction{PersonID: 1, CandidateID: 2, Kind: constant.ReactionLike, CreatedAt: now.Add(-2 * time.Hour)}
+	fresh := entity.Reaction{PersonID: 2, CandidateID: 1, Kind: constant.ReactionLike, CreatedAt: now}
+	pass := entity.Reaction{PersonID: 1, CandidateID: 3, Kind: constant.ReactionPass, CreatedAt: now.Add(-2 * time.Hour)}
+	for _, r := range []entity.Reaction{expired, fresh, pass} {
+		assert.Nil(t, h.reactions.Put(r))
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	_, err := NewSnapshotHandler(h, path).SaveSnapshot(context.Background())
+	assert.Nil(t, err)
+
+	// Passes do not expire
+	want := []entity.Reaction{pass, fresh}
+	got, err := h.reactions.List()
+	assert.Nil(t, err)
+	assert.Equal(t, want, got, "the expired like should be deleted from the store")
+	snap, _, err := snapshot.Load(path)
+	assert.Nil(t, err)
+	assert.Len(t, snap.Reactions, 2)
+}
+
+// utcMatches drops the location and the monotonic clock, which a snapshot does not keep
+func utcMatches(matches []entity.Match) []entity.Match {
+	for i := range matches {
+		matches[i].CreatedAt = matches[i].CreatedAt.UTC()
+	}
+	return matches
+}
+
+func utcTimes(times map[uint64]time.Time) map[uint64]time.Time {
+	for id, at := range times {
+		times[id] = at.UTC()
+	}
+	return times
+}
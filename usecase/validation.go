@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+const (
+	minHeight       = 50
+	maxHeight       = 300
+	maxNameLength   = 100
+	maxWantedDates  = 1000
+	minAge          = 18
+	maxAge          = 130
+	maxCityLength   = 100
+	maxTags         = 20
+	maxTagLength    = 32
+	maxReasonLength = 500
+)
+
+// validatePair returns a ValidationError listing the missing IDs of a pair to match
+func validatePair(pair PersonPair) error {
+	var fields []cGin.FieldError
+	if pair.ID1 == 0 {
+		fields = append(fields, cGin.FieldError{Field: "id1", Message: "must be greater than 0"})
+	}
+	if pair.ID2 == 0 {
+		fields = append(fields, cGin.FieldError{Field: "id2", Message: "must be greater than 0"})
+	}
+
+	if len(fields) > 0 {
+		return cGin.ValidationError{
+			CustomError: ErrorInvalidPair,
+			Fields:      fields,
+		}
+	}
+	return nil
+}
+
+// validatePerson returns a ValidationError listing every invalid field of p
+func validatePerson(p entity.Person) error {
+	var fields []cGin.FieldError
+
+	switch name := strings.TrimSpace(p.Name); {
+	case name == "":
+		fields = append(fields, cGin.FieldError{Field: "name", Message: "must not be empty"})
+	case utf8.RuneCountInString(name) > maxNameLength:
+		fields = append(fields, cGin.FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", maxNameLength)})
+	}
+
+	// Written as a range test so NaN, which compares false, is refused as well
+	if !(p.Height >= minHeight && p.Height <= maxHeight) {
+		fields = append(fields, cGin.FieldError{Field: "height", Message: fmt.Sprintf("must be between %d and %d", minHeight, maxHeight)})
+	}
+
+	if !validGender(p.Gender) {
+		fields = append(fields, cGin.FieldError{Field: "gender", Message: "must be one of " + genderList()})
+	}
+
+	if msg := validateSeeking(p.Seeking); msg != "" {
+		fields = append(fields, cGin.FieldError{Field: "seeking", Message: msg})
+	}
+
+	if p.WantedDates == nil || *p.WantedDates == 0 || *p.WantedDates > maxWantedDates {
+		fields = append(fields, cGin.FieldError{Field: "wantedDate", Message: fmt.Sprintf("must be between 1 and %d", maxWantedDates)})
+	}
+
+	// Age, city, location and tags are optional
+	if p.Age != 0 && (p.Age < minAge || p.Age > maxAge) {
+		fields = append(fields, cGin.FieldError{Field: "age", Message: fmt.Sprintf("must be between %d and %d", minAge, maxAge)})
+	}
+
+	if utf8.RuneCountInString(p.City) > maxCityLength {
+		fields = append(fields, cGin.FieldError{Field: "city", Message: fmt.Sprintf("must be at most %d characters", maxCityLength)})
+	}
+
+	if l := p.Location; l != nil && !(l.Latitude >= -90 && l.Latitude <= 90 && l.Longitude >= -180 && l.Longitude <= 180) {
+		fields = append(fields, cGin.FieldError{Field: "location", Message: "latitude must be between -90 and 90 and longitude between -180 and 180"})
+	}
+
+	if msg := validateTags(p.Tags); msg != "" {
+		fields = append(fields, cGin.FieldError{Field: "tags", Message: msg})
+	}
+
+	if len(fields) > 0 {
+		return cGin.ValidationError{
+			CustomError: ErrorInvalidPerson,
+			Fields:      fields,
+		}
+	}
+	return nil
+}
+
+func validGender(g constant.Gender) bool {
+	for _, valid := range constant.Genders {
+		if g == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func genderList() string {
+	names := make([]string, len(constant.Genders))
+	for i, g := range constant.Genders {
+		names[i] = string(g)
+	}
+	return strings.Join(names, " ")
+}
+
+// validateSeeking returns why seeking is invalid, empty if it is valid
+func validateSeeking(seeking []constant.Gender) string {
+	if len(seeking) == 0 {
+		return "must not be empty"
+	}
+
+	seen := map[constant.Gender]bool{}
+	for _, g := range seeking {
+		if !validGender(g) {
+			return "must only contain " + genderList()
+		}
+		if seen[g] {
+			return fmt.Sprintf("must not repeat %s", g)
+		}
+		seen[g] = true
+	}
+	return ""
+}
+
+// validateTags returns why normalized tags are invalid, empty if they are valid
+func validateTags(tags []string) string {
+	if len(tags) > maxTags {
+		return fmt.Sprintf("must have at most %d tags", maxTags)
+	}
+
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return fmt.Sprintf("must each be at most %d characters", maxTagLength)
+		}
+		// Tags are stored comma separated
+		if strings.Contains(tag, ",") {
+			return "must not contain commas"
+		}
+		if seen[tag] {
+			return fmt.Sprintf("must not repeat %s", tag)
+		}
+		seen[tag] = true
+	}
+	return ""
+}
+
+// validateFilter returns ErrorInvalidFilter when f has a negative bound or an empty age range
+func validateFilter(f CandidateFilter) error {
+	if f.MinAge < 0 || f.MaxAge < 0 || !(f.MaxDistanceKm >= 0 && f.MaxDistanceKm <= math.MaxFloat64) {
+		return ErrorInvalidFilter
+	}
+	if f.MaxAge > 0 && f.MinAge > f.MaxAge {
+		return ErrorInvalidFilter
+	}
+	return nil
+}
+
+// validateReason returns a ValidationError when the reason of a report is empty or too long
+func validateReason(reason string) error {
+	var msg string
+	switch {
+	case strings.TrimSpace(reason) == "":
+		msg = "must not be empty"
+	case utf8.RuneCountInString(reason) > maxReasonLength:
+		msg = fmt.Sprintf("must be at most %d characters", maxReasonLength)
+	default:
+		return nil
+	}
+
+	return cGin.ValidationError{
+		CustomError: ErrorInvalidReport,
+		Fields:      []cGin.FieldError{{Field: "reason", Message: msg}},
+	}
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/util/cGin"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func Test_validatePerson(t *testing.T) {
+	valid := entity.Person{
+		Name:        "a",
+		Height:      170,
+		Gender:      "male",
+		Seeking:     []constant.Gender{constant.GenderFemale},
+		WantedDates: cTypes.Uint64(1),
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(p *entity.Person)
+		wantFields []string
+	}{
+		{"Valid", func(p *entity.Person) {}, nil},
+		{"Empty name", func(p *entity.Person) { p.Name = "  " }, []string{"name"}},
+		{"Long name", func(p *entity.Person) { p.Name = strings.Repeat("a", maxNameLength+1) }, []string{"name"}},
+		{"Negative height", func(p *entity.Person) { p.Height = -1 }, []string{"height"}},
+		{"Absurd height", func(p *entity.Person) { p.Height = 1000 }, []string{"height"}},
+		{"NaN height", func(p *entity.Person) { p.Height = math.NaN() }, []string{"height"}},
+		{"Infinite height", func(p *entity.Person) { p.Height = math.Inf(1) }, []string{"height"}},
+		{"Unknown gender", func(p *entity.Person) { p.Gender = "robot" }, []string{"gender"}},
+		{"Non-binary", func(p *entity.Person) { p.Gender = constant.GenderNonBinary }, nil},
+		{"Seeking nobody", func(p *entity.Person) { p.Seeking = nil }, []string{"seeking"}},
+		{"Seeking unknown gender", func(p *entity.Person) { p.Seeking = []constant.Gender{"robot"} }, []string{"seeking"}},
+		{
+			"Seeking repeated gender",
+			func(p *entity.Person) { p.Seeking = []constant.Gender{constant.GenderMale, constant.GenderMale} },
+			[]string{"seeking"},
+		},
+		{"Underage", func(p *entity.Person) { p.Age = 17 }, []string{"age"}},
+		{"Adult", func(p *entity.Person) { p.Age = 18 }, nil},
+		{"Long city", func(p *entity.Person) { p.City = strings.Repeat("a", maxCityLength+1) }, []string{"city"}},
+		{"Off the globe", func(p *entity.Person) { p.Location = &entity.Location{Latitude: 91} }, []string{"location"}},
+		{"NaN latitude", func(p *entity.Person) { p.Location = &entity.Location{Latitude: math.NaN()} }, []string{"location"}},
+		{"Infinite longitude", func(p *entity.Person) { p.Location = &entity.Location{Longitude: math.Inf(-1)} }, []string{"location"}},
+		{"Repeated tag", func(p *entity.Person) { p.Tags = []string{"jazz", "jazz"} }, []string{"tags"}},
+		{"Tag with comma", func(p *entity.Person) { p.Tags = []string{"rock,pop"} }, []string{"tags"}},
+		{"Missing wanted date", func(p *entity.Person) { p.WantedDates = nil }, []string{"wantedDate"}},
+		{"Zero wanted date", func(p *entity.Person) { p.WantedDates = cTypes.Uint64(0) }, []string{"wantedDate"}},
+		{
+			"Every field",
+			func(p *entity.Person) { *p = entity.Person{} },
+			[]string{"name", "height", "gender", "seeking", "wantedDate"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.modify(&p)
+
+			err := validatePerson(p)
+			if tt.wantFields == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			vErr, ok := err.(cGin.ValidationError)
+			assert.True(t, ok, "error should be a ValidationError")
+			assert.Equal(t, ErrorInvalidPerson, vErr.CustomError)
+
+			var gotFields []string
+			for _, f := range vErr.Fields {
+				gotFields = append(gotFields, f.Field)
+			}
+			assert.Equal(t, tt.wantFields, gotFields)
+		})
+	}
+}
+
+func (s *personTestSuite) Test_AddPersonInvalid() {
+	_, err := s.h.AddPerson(entity.Person{
+		Name:        "a",
+		Height:      170,
+		Gender:      "unknown",
+		WantedDates: cTypes.Uint64(1),
+	})
+	assert.IsType(s.T(), cGin.ValidationError{}, err)
+	assert.Equal(s.T(), uint64(0), *s.h.id, "invalid person should not consume an ID")
+}
+
+func (s *personTestSuite) Test_AddPersonNonBinaryWithoutSeeking() {
+	_, err := s.h.AddPerson(entity.Person{
+		Name:        "a",
+		Height:      170,
+		Gender:      constant.GenderNonBinary,
+		WantedDates: cTypes.Uint64(1),
+	})
+	assert.IsType(s.T(), cGin.ValidationError{}, err, "non-binary has no default seeking")
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ars0915/matching-system/internal/wal"
+)
+
+// logged appends r to the write-ahead log and then applies it. Both happen
+// under walMu so records are replayed in the order they were applied.
+func (h *PersonHandler) logged(r wal.Record, apply func(at time.Time) error) error {
+	r.Time = time.Now()
+	if h.wal == nil {
+		return apply(r.Time)
+	}
+
+	h.walMu.Lock()
+	defer h.walMu.Unlock()
+
+	if _, err := h.wal.Append(r); err != nil {
+		return errors.Wrap(err, "append wal")
+	}
+	return apply(r.Time)
+}
+
+// loggedBatch is logged for the records of a bulk request, which the log splits
+// into as many OpBatch records as it takes to stay under its record size
+func (h *PersonHandler) loggedBatch(records []wal.Record, apply func(at time.Time) error) error {
+	at := time.Now()
+	if h.wal == nil {
+		return apply(at)
+	}
+
+	h.walMu.Lock()
+	defer h.walMu.Unlock()
+
+	if err := h.wal.AppendBatch(records, at); err != nil {
+		return errors.Wrap(err, "append wal")
+	}
+	return apply(at)
+}
+
+// replay applies records without logging them again. Operations that failed
+// when they were logged fail again here, so errors are only reported.
+func (h *PersonHandler) replay(records []wal.Record) {
+	for _, r := range records {
+		var err error
+		switch r.Op {
+		case wal.OpAddPerson:
+			if r.Person == nil {
+				continue
+			}
+			if *h.id < r.Person.ID {
+				*h.id = r.Person.ID
+			}
+			// Records written before seeking existed carry none
+			withDefaultSeeking(r.Person)
+			err = h.addPerson(r.Person, r.Time)
+		case wal.OpRemovePerson:
+			err = h.removePerson(r.ID, r.Time)
+		case wal.OpUpdatePerson:
+			if r.Patch == nil {
+				continue
+			}
+			_, err = h.updatePerson(r.ID, *r.Patch, r.Time)
+		case wal.OpMatch:
+			_, err = h.match(r.ID1, r.ID2, r.Time, false)
+		case wal.OpMutualMatch:
+			_, err = h.match(r.ID1, r.ID2, r.Time, true)
+		case wal.OpLike:
+			var result LikeResult
+			if result, err = h.like(r.ID1, r.ID2, r.Time); err == nil {
+				err = result.MatchErr
+			}
+		case wal.OpPass:
+			err = h.pass(r.ID1, r.ID2, r.Time)
+		case wal.OpBlock:
+			err = h.block(r.ID1, r.ID2, r.Time)
+		case wal.OpUnblock:
+			err = h.unblock(r.ID1, r.ID2)
+		case wal.OpReport:
+			if r.Report == nil {
+				continue
+			}
+			err = h.report(r.Report, r.Time)
+		case wal.OpResolveReport:
+			if r.Report == nil {
+				continue
+			}
+			_, err = h.resolveReport(r.Report.ID, r.Report.Status, r.Time)
+		case wal.OpBatch:
+			for i := range r.Batch {
+				r.Batch[i].Seq, r.Batch[i].Time = r.Seq, r.Time
+			}
+			h.replay(r.Batch)
+		case wal.OpSuspend:
+			err = h.suspend(r.ID, r.Time)
+		case wal.OpUnsuspend:
+			err = h.unsuspend(r.ID, r.Time)
+		}
+
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"seq": r.Seq,
+				"op":  r.Op,
+			}).WithError(err).Debug("Replayed wal record failed")
+		}
+	}
+}
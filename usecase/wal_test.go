@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ars0915/matching-system/constant"
+	"github.com/ars0915/matching-system/entity"
+	"github.com/ars0915/matching-system/internal/wal"
+	"github.com/ars0915/matching-system/util/cTypes"
+)
+
+func (s *personTestSuite) Test_WALReplay() {
+	path := filepath.Join(s.T().TempDir(), "matching.wal")
+	l, _, err := wal.Open(path, 0)
+	assert.Nil(s.T(), err)
+
+	boy := entity.Person{ID: 1, Name: "a", Height: 180, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(2)}
+	girl := entity.Person{ID: 2, Name: "b", Height: 160, Gender: constant.GenderFemale, WantedDates: cTypes.Uint64(1)}
+	matchedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, r := range []wal.Record{
+		{Op: wal.OpAddPerson, Person: &boy},
+		{Op: wal.OpAddPerson, Person: &girl},
+		{Op: wal.OpMatch, ID1: 1, ID2: 2, Time: matchedAt},
+	} {
+		_, err := l.Append(r)
+		assert.Nil(s.T(), err)
+	}
+	l.Close()
+
+	l, pending, err := wal.Open(path, 0)
+	assert.Nil(s.T(), err)
+	defer l.Close()
+
+	people := map[uint64]*entity.Person{}
+	s.boys.EXPECT().AddPerson(gomock.Any()).DoAndReturn(func(p *entity.Person) error {
+		people[p.ID] = p
+		return nil
+	})
+	s.girls.EXPECT().AddPerson(gomock.Any()).DoAndReturn(func(p *entity.Person) error {
+		people[p.ID] = p
+		return nil
+	})
+	s.boys.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		p, exist := people[id]
+		return p, exist && p.Gender == constant.GenderMale
+	}).AnyTimes()
+	s.girls.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id uint64) (*entity.Person, bool) {
+		p, exist := people[id]
+		return p, exist && p.Gender == constant.GenderFemale
+	}).AnyTimes()
+	s.boys.EXPECT().UpdatePerson(gomock.Any()).Return(nil)
+	s.girls.EXPECT().UpdatePerson(gomock.Any()).Return(nil)
+	s.girls.EXPECT().RemovePerson(girl.ID).Return(nil)
+	s.matches.EXPECT().AddMatch(wantMatch(1, 2, matchedAt)).Return(nil)
+	// Matches logged before likes existed are replayed without them
+	s.reactions.EXPECT().Find(gomock.Any(), gomock.Any()).Return(nil, false, nil).Times(2)
+	s.reactions.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	h := NewPersonHandler(s.trees(), s.matches, s.reactions, s.reports, WithWAL(l, pending))
+	assert.Equal(s.T(), uint64(1), *people[boy.ID].WantedDates)
+	assert.Equal(s.T(), uint64(0), *people[girl.ID].WantedDates)
+
+	// New mutations continue both the ID and the log sequence
+	s.boys.EXPECT().AddPerson(gomock.Any()).Return(nil)
+	p, err := h.AddPerson(entity.Person{Name: "c", Height: 170, Gender: constant.GenderMale, WantedDates: cTypes.Uint64(1)})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(3), p.ID)
+	assert.Equal(s.T(), uint64(4), l.Seq())
+}
+
+func (s *personTestSuite) Test_WALReplayLikes() {
+	path := filepath.Join(s.T().TempDir(), "matching.wal")
+	l, _, err := wal.Open(path, 0)
+	assert.Nil(s.T(), err)
+
+	likedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, r := range []wal.Record{
+		{Op: wal.OpLike, ID1: 1, ID2: 2, Time: likedAt},
+		{Op: wal.OpLike, ID1: 2, ID2: 1, Time: likedAt.Add(time.Minute)},
+	} {
+		_, err := l.Append(r)
+		assert.Nil(s.T(), err)
+	}
+	l.Close()
+
+	l, pending, err := wal.Open(path, 0)
+	assert.Nil(s.T(), err)
+	defer l.Close()
+
+	s.boys.EXPECT().UpdatePerson(gomock.Any()).Return(nil)
+	s.girls.EXPECT().UpdatePerson(gomock.Any()).Return(nil)
+	s.matches.EXPECT().AddMatch(wantMatch(2, 1, likedAt.Add(time.Minute))).Return(nil)
+
+	_, people := s.initCouple(WithWAL(l, pending))
+	assert.Equal(s.T(), uint64(1), *people[0].WantedDates, "the second like should replay the match")
+}
+
+func wantMatch(id1, id2 uint64, at time.Time) gomock.Matcher {
+	return gomock.Eq(&entity.Match{
+		PersonID1: id1,
+		PersonID2: id2,
+		Status:    constant.MatchStatusMatched,
+		CreatedAt: at,
+	})
+}
@@ -2,9 +2,9 @@ package cGin
 
 // CustomError custom error
 type CustomError struct {
-	HTTPCode int
-	Code     int
-	Message  string
+	HTTPCode int    `json:"httpStatus"`
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
 }
 
 func (cErr CustomError) Error() string {
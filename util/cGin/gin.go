@@ -16,8 +16,11 @@ import (
 
 type meta struct {
 	*paging.Paginator
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+	// Next is the cursor of the next page, empty on the last page
+	Next string `json:"next,omitempty"`
 }
 
 // func (p meta) MarshalJSON() ([]byte, error) {
@@ -91,7 +94,7 @@ func (c *Context) GetPaginator() paging.Paginator {
 	limitStr, limitOK := c.GetQuery(paging.LimitKeyName)
 	if limitOK {
 		limit, err = strconv.Atoi(limitStr)
-		if err != nil || limit == 0 || limit > paging.DefaultMaxLimit {
+		if err != nil || limit <= 0 || limit > paging.DefaultMaxLimit {
 			limit = paging.DefaultLimit
 		}
 	}
@@ -131,6 +134,12 @@ func (c *Context) WithPaginator(page paging.Paginator) *Context {
 	return c
 }
 
+// WithNext set the cursor of the next page
+func (c *Context) WithNext(cursor string) *Context {
+	c.wrap.Meta.Next = cursor
+	return c
+}
+
 // WithData set response data
 func (c *Context) WithData(data interface{}) *Context {
 	c.wrap.Data = data
@@ -186,6 +195,10 @@ func (c *Context) beforeResponse() *Context {
 	case CustomError:
 		c.customError = &cErr
 		c.code = cErr.Code
+	case ValidationError:
+		c.customError = &cErr.CustomError
+		c.code = cErr.Code
+		c.wrap.Meta.Errors = cErr.Fields
 	}
 	return c
 }
@@ -210,3 +223,18 @@ func (c *Context) Value(key interface{}) interface{} {
 	}
 	return c.Context.Value(key)
 }
+
+// ErrorMeta returns the code, message and invalid fields a response of err carries,
+// httpCode and msg are used when err is not a CustomError
+func ErrorMeta(err error, httpCode int, msg string) (code int, message string, fields []FieldError) {
+	switch cErr := cError.Unwrap(err).(type) {
+	case CustomError:
+		return cErr.Code, cErr.Message, nil
+	case ValidationError:
+		return cErr.Code, cErr.Message, cErr.Fields
+	}
+	if prefixCode != nil {
+		httpCode = (*prefixCode * 1000) + httpCode
+	}
+	return httpCode, msg, nil
+}
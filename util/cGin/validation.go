@@ -0,0 +1,77 @@
+package cGin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+)
+
+// FieldError describes why a request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is a CustomError that also lists the invalid fields
+type ValidationError struct {
+	CustomError
+	Fields []FieldError
+}
+
+func init() {
+	// Report binding errors with the json name of the field
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// BindingError converts validator errors returned by ShouldBind into a
+// ValidationError based on cErr, other errors are returned unchanged
+func BindingError(cErr CustomError, err error) error {
+	var vErrs validator.ValidationErrors
+	if !errors.As(err, &vErrs) {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(vErrs))
+	for _, fe := range vErrs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Message: ruleMessage(fe),
+		})
+	}
+
+	return ValidationError{
+		CustomError: cErr,
+		Fields:      fields,
+	}
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	}
+	return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+}
@@ -0,0 +1,149 @@
+// Package cGrpc converts the errors of the usecases into gRPC statuses, as cGin does for HTTP.
+package cGrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"github.com/ars0915/matching-system/util/cError"
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail carrying the code of a CustomError
+const ErrorDomain = "matching-system"
+
+// httpCodes maps the HTTP status of a CustomError to the gRPC code closest to it
+var httpCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+var (
+	// errorCodes maps the code of a CustomError whose HTTP status is too coarse, see SetCode
+	errorCodes   = map[int]codes.Code{}
+	errorCodesMu sync.RWMutex
+)
+
+// SetCode makes cErr map to code rather than to the gRPC code of its HTTP status
+func SetCode(cErr cGin.CustomError, code codes.Code) {
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+
+	errorCodes[cErr.Code] = code
+}
+
+// Code returns the gRPC code of a CustomError, the one set with SetCode first
+func Code(cErr cGin.CustomError) codes.Code {
+	errorCodesMu.RLock()
+	code, ok := errorCodes[cErr.Code]
+	errorCodesMu.RUnlock()
+	if ok {
+		return code
+	}
+
+	if code, ok := httpCodes[cErr.HTTPCode]; ok {
+		return code
+	}
+	if cErr.HTTPCode >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.FailedPrecondition
+}
+
+// Status converts err into a gRPC status error. A CustomError keeps its message and
+// carries its code in an ErrorInfo detail, a ValidationError also lists the invalid
+// fields in a BadRequest detail, context errors become Canceled or DeadlineExceeded
+// and other errors become Internal without their message.
+func Status(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var (
+		cErr   cGin.CustomError
+		fields []cGin.FieldError
+	)
+	switch e := cError.Unwrap(err).(type) {
+	case cGin.CustomError:
+		cErr = e
+	case cGin.ValidationError:
+		cErr, fields = e.CustomError, e.Fields
+	default:
+		return status.Error(codes.Internal, "Internal Server Error")
+	}
+
+	st := status.New(Code(cErr), cErr.Message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: strconv.Itoa(cErr.Code),
+		Domain: ErrorDomain,
+	}}
+	if len(fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+		for i, f := range fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// UnaryServerInterceptor converts the errors of the handlers with Status and logs them
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		logError(info.FullMethod, err)
+	}
+	return resp, Status(err)
+}
+
+// StreamServerInterceptor converts the errors of the stream handlers with Status and logs them
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	if err != nil {
+		logError(info.FullMethod, err)
+	}
+	return Status(err)
+}
+
+func logError(method string, err error) {
+	st := status.Convert(Status(err))
+	grpcLog := logrus.WithFields(logrus.Fields{
+		"grpcMethod": method,
+		"grpcCode":   st.Code().String(),
+		"err":        err,
+	})
+
+	switch st.Code() {
+	case codes.Internal, codes.Unknown, codes.Unavailable:
+		grpcLog.Error(st.Message())
+	case codes.Canceled, codes.DeadlineExceeded:
+		grpcLog.Info(st.Message())
+	default:
+		grpcLog.Warn(st.Message())
+	}
+}
@@ -0,0 +1,35 @@
+package cGrpc
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ars0915/matching-system/util/cGin"
+)
+
+func Test_Status(t *testing.T) {
+	exist := cGin.CustomError{Code: 1, HTTPCode: http.StatusConflict, Message: "exist"}
+	SetCode(exist, codes.AlreadyExists)
+
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"Set code", exist, codes.AlreadyExists},
+		{"Conflict", cGin.CustomError{Code: 2, HTTPCode: http.StatusConflict}, codes.FailedPrecondition},
+		{"Not found", cGin.CustomError{Code: 3, HTTPCode: http.StatusNotFound}, codes.NotFound},
+		{"Validation", cGin.ValidationError{CustomError: cGin.CustomError{Code: 4, HTTPCode: http.StatusBadRequest}}, codes.InvalidArgument},
+		{"Other error", assert.AnError, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, status.Code(Status(tt.err)))
+		})
+	}
+}
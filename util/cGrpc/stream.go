@@ -0,0 +1,36 @@
+package cGrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StopStreamsInterceptor cancels the context of every stream once ctx is done, so a
+// graceful stop does not wait for the streams that only end when the client leaves.
+// The streams ended this way fail with Unavailable.
+func StopStreamsInterceptor(ctx context.Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		streamCtx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: streamCtx})
+		if err != nil && ctx.Err() != nil {
+			return status.Error(codes.Unavailable, "Server is shutting down")
+		}
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -10,6 +10,10 @@ const (
 	DefaultLimit    = 20
 	DefaultMaxLimit = 5000
 	DefaultPage     = 1
+
+	// Unlimited is the Limit of a page holding every record, for snapshots and tests.
+	// A request never gets it, cGin.GetPaginator refuses a negative limit.
+	Unlimited = -1
 )
 
 // Paginator page data